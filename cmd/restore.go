@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/unicrons/steampipe-config-generator/internal/configfile"
+	"github.com/unicrons/steampipe-config-generator/internal/logger"
+)
+
+// NewRestoreCmd builds the "restore" subcommand, which rolls the generated files back to one of
+// the backups kept by the root command.
+func NewRestoreCmd() *cobra.Command {
+	var (
+		credentialPath  string
+		connectionsPath string
+		backupID        string
+		backups         int
+		list            bool
+		logFormat       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore",
+		Short: "Restore the generated files from a backup",
+		Long: "Restore the AWS credentials and config files and the Steampipe connections file from the backups made before " +
+			"they were last replaced: by default the most recent one, or the one given with --backup (see --list). " +
+			"The current files are backed up first, so running restore again undoes it.",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(validLogFormats, logFormat) {
+				return fmt.Errorf("--log unknown value. Valid values are: default, json")
+			}
+			if backups < 0 {
+				return fmt.Errorf("--backups must not be negative")
+			}
+			if err := applyPathDefaults(&credentialPath, &connectionsPath); err != nil {
+				return err
+			}
+
+			files := []string{
+				filepath.Join(credentialPath, "credentials"),
+				filepath.Join(credentialPath, "config"),
+				filepath.Join(connectionsPath, "aws.spc"),
+			}
+			if list {
+				return listBackups(cmd, files)
+			}
+			return restoreBackups(logger.New(logFormat), files, backupID, configfile.WriteOptions{Backups: backups, BackupTime: time.Now()})
+		},
+	}
+
+	cmd.Flags().StringVar(&credentialPath, "path", "", "AWS Credentials and config files path")
+	cmd.Flags().StringVar(&connectionsPath, "connections", "", "Steampipe AWS connections file path")
+	cmd.Flags().StringVar(&backupID, "backup", "", "Backup to restore, as printed by --list. Defaults to the most recent one")
+	cmd.Flags().IntVar(&backups, "backups", defaultBackups, "Number of timestamped backups of each file to keep, including the one of the files being replaced. 0 disables backups")
+	cmd.Flags().BoolVar(&list, "list", false, "List the available backups instead of restoring one")
+	cmd.Flags().StringVar(&logFormat, "log", "default", "Log format: default, json")
+
+	return cmd
+}
+
+func listBackups(cmd *cobra.Command, files []string) error {
+	for _, file := range files {
+		backups, err := configfile.ListBackups(file)
+		if err != nil {
+			return err
+		}
+		for _, b := range backups {
+			if _, err := fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", configfile.BackupID(b.Time), b.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restoreBackups restores each of files that has a backup with the given ID, or with the most
+// recent ID among all of files' backups if id is empty. Files backed up in the same run share an
+// ID, so they're restored together.
+func restoreBackups(log *slog.Logger, files []string, id string, opts configfile.WriteOptions) error {
+	if id == "" {
+		var latest time.Time
+		for _, file := range files {
+			backups, err := configfile.ListBackups(file)
+			if err != nil {
+				return err
+			}
+			if len(backups) > 0 && backups[0].Time.After(latest) {
+				latest = backups[0].Time
+			}
+		}
+		if latest.IsZero() {
+			return fmt.Errorf("no backups found")
+		}
+		id = configfile.BackupID(latest)
+	}
+
+	restored := false
+	for _, file := range files {
+		b, ok, err := configfile.FindBackup(file, id)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := configfile.Restore(file, b, opts); err != nil {
+			return fmt.Errorf("restoring %s: %w", file, err)
+		}
+		log.Info("restored file from backup", "path", file, "backup", id)
+		restored = true
+	}
+	if !restored {
+		return fmt.Errorf("no backup %q found", id)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package cmd_test
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unicrons/steampipe-config-generator/cmd"
+	"github.com/unicrons/steampipe-config-generator/internal/configfile"
+)
+
+func noRun(t *testing.T) runFunc {
+	return func(context.Context, *slog.Logger, *cmd.Flags) error {
+		t.Fatal("the root command should not run for restore")
+		return nil
+	}
+}
+
+// writeVersions writes each version of file in turn, as separate runs keeping backups would.
+func writeVersions(t *testing.T, file string, start time.Time, versions ...string) {
+	t.Helper()
+	for i, content := range versions {
+		opts := configfile.WriteOptions{Backups: 5, BackupTime: start.Add(time.Duration(i) * time.Minute)}
+		if err := configfile.WriteFile(file, []byte(content), opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func readString(t *testing.T, file string) string {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRestoreCmd_Latest(t *testing.T) {
+	credsDir, connDir := t.TempDir(), t.TempDir()
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	writeVersions(t, filepath.Join(credsDir, "credentials"), start, "creds v1", "creds v2")
+	writeVersions(t, filepath.Join(connDir, "aws.spc"), start, "conn v1", "conn v2")
+
+	if _, err := execute(t, noRun(t), "restore", "--path", credsDir, "--connections", connDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := readString(t, filepath.Join(credsDir, "credentials")); got != "creds v1" {
+		t.Errorf("credentials = %q, want %q", got, "creds v1")
+	}
+	if got := readString(t, filepath.Join(connDir, "aws.spc")); got != "conn v1" {
+		t.Errorf("aws.spc = %q, want %q", got, "conn v1")
+	}
+
+	// The restore backed up the replaced files, so restoring again undoes it.
+	if _, err := execute(t, noRun(t), "restore", "--path", credsDir, "--connections", connDir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readString(t, filepath.Join(credsDir, "credentials")); got != "creds v2" {
+		t.Errorf("credentials = %q, want %q", got, "creds v2")
+	}
+}
+
+func TestRestoreCmd_ListAndSelect(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "credentials")
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	writeVersions(t, file, start, "v1", "v2", "v3")
+
+	out, err := execute(t, noRun(t), "restore", "--list", "--path", dir, "--connections", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	oldest := configfile.BackupID(start.Add(time.Minute))
+	if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 2 || !strings.HasPrefix(lines[1], oldest) {
+		t.Fatalf("--list output = %q, want 2 backups, the oldest %s last", out, oldest)
+	}
+
+	if _, err := execute(t, noRun(t), "restore", "--backup", oldest, "--path", dir, "--connections", dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readString(t, file); got != "v1" {
+		t.Errorf("credentials = %q, want %q", got, "v1")
+	}
+}
+
+func TestRestoreCmd_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeVersions(t, filepath.Join(dir, "credentials"), time.Now(), "v1", "v2")
+
+	tests := map[string][]string{
+		"no backups":       {"restore", "--path", t.TempDir(), "--connections", t.TempDir()},
+		"unknown backup":   {"restore", "--path", dir, "--connections", dir, "--backup", "20000101T000000.000Z"},
+		"negative backups": {"restore", "--path", dir, "--connections", dir, "--backups", "-1"},
+	}
+	for name, args := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := execute(t, noRun(t), args...); err == nil {
+				t.Errorf("expected an error for args %v", args)
+			}
+		})
+	}
+}
@@ -2,15 +2,21 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 
+	"github.com/unicrons/steampipe-config-generator/generator"
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
 	"github.com/unicrons/steampipe-config-generator/internal/logger"
 )
 
@@ -19,6 +25,15 @@ import (
 type Flags struct {
 	RoleName         string
 	CredentialSource string
+	SourceProfile    string
+	SSOStartURL      string
+	SSORegion        string
+	SSOSession       string
+	PermissionSets   []string
+	ExternalID       string
+	DurationSeconds  int
+	RoleSessionName  string
+	MFASerial        string
 	CredentialPath   string
 	ConnectionsPath  string
 	ImportSchema     string
@@ -27,14 +42,73 @@ type Flags struct {
 	AssumeRoleArn    string
 	TemplatePath     string
 	LogFormat        string
+	IncludeOUs       []string
 	SkipOUs          []string
 	TagSplit         map[string]string
+	TagFilter        string
+	IncludeAccounts  []string
+	ExcludeAccounts  []string
+	NameCollision    string
+	NamingScheme     string
+	AggregateByTag   []string
+	AggregateByOU    bool
+	AggregatorPrefix string
+	MergeCredentials bool
+	MergeConnections bool
+	Backups          int
+	DryRun           bool
+	// ProfileFiles selects which AWS files get the generated profiles: "credentials", "config"
+	// or "both".
+	ProfileFiles  string
+	ProfileRegion bool
+	MergeConfig   bool
+	// CredentialsMode, ConnectionsMode and DirMode are the permissions of the credentials file,
+	// the connections file and their directories when created. Existing ones keep their own.
+	CredentialsMode fs.FileMode
+	ConnectionsMode fs.FileMode
+	DirMode         fs.FileMode
+	// OverrideTagPrefix prefixes the account tags that override settings per account, empty if
+	// disabled.
+	OverrideTagPrefix string
+	// RolePath is the IAM path of --role, and RoleRules the --roleRule occurrences, in order.
+	RolePath  string
+	RoleRules []RoleRule
+	// DiscoverRegions selects how to list each account's enabled regions: "organization" or
+	// "member-role", empty if disabled.
+	DiscoverRegions string
 }
 
+// RoleRule is a parsed --roleRule: the role of the accounts under OU, or tagged TagKey with a
+// value matching the TagValue glob. An empty RoleName or RolePath keeps --role's or
+// --rolePath's.
+type RoleRule struct {
+	OU       string
+	TagKey   string
+	TagValue string
+	RoleName string
+	RolePath string
+}
+
+// WritesCredentials reports whether the generated profiles are written to the AWS credentials
+// file.
+func (f *Flags) WritesCredentials() bool {
+	return f.ProfileFiles != "config"
+}
+
+// WritesConfig reports whether the generated profiles are written to the AWS config file.
+func (f *Flags) WritesConfig() bool {
+	return f.ProfileFiles == "config" || f.ProfileFiles == "both"
+}
+
+// defaultBackups is the default number of backups kept of each generated file.
+const defaultBackups = 5
+
 var (
 	validCredentialSources = []string{"Ec2InstanceMetadata", "Environment", "EcsContainer"}
 	validImportSchemas     = []string{"enabled", "disabled"}
 	validLogFormats        = []string{"default", "json"}
+	validProfileFiles      = []string{"credentials", "config", "both"}
+	validDiscoverRegions   = []string{"organization", "member-role"}
 )
 
 // NewRootCmd builds the root command. run is invoked with the request context, a logger
@@ -43,15 +117,36 @@ func NewRootCmd(run func(ctx context.Context, log *slog.Logger, flags *Flags) er
 	var (
 		flags         Flags
 		targetRegions string
+		includeOUs    string
 		skipOUs       string
+		aggregateTags string
+		permSets      string
 		rawTagSplit   []string
+		rawInclude    []string
+		rawExclude    []string
+		rawRoleRules  []string
+		credsMode     string
+		connMode      string
+		dirMode       string
 	)
 
 	cmd := &cobra.Command{
 		Use:          "steampipe-config-generator",
 		Short:        "Generate Steampipe AWS connection config files from an AWS Organization",
 		SilenceUsage: true,
+		// main prints errors, except the one a --dryRun run finding changes returns to set the
+		// exit code. Like SilenceUsage, it applies to every subcommand.
+		SilenceErrors: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			// --credential and --profileFiles have defaults, which only an explicit value should
+			// conflict with.
+			if (flags.SourceProfile != "" || flags.SSOStartURL != "") && !cmd.Flags().Changed("credential") {
+				flags.CredentialSource = ""
+			}
+			if flags.SSOStartURL != "" && !cmd.Flags().Changed("profileFiles") {
+				flags.ProfileFiles = "config"
+			}
+			flags.PermissionSets = splitList(permSets)
 			if err := validateFlagValues(&flags); err != nil {
 				return err
 			}
@@ -61,10 +156,28 @@ func NewRootCmd(run func(ctx context.Context, log *slog.Logger, flags *Flags) er
 				return err
 			}
 			flags.TagSplit = tagSplit
+			flags.IncludeAccounts = parseAccountPatterns(rawInclude)
+			flags.ExcludeAccounts = parseAccountPatterns(rawExclude)
+			if flags.RoleRules, err = parseRoleRules(rawRoleRules); err != nil {
+				return err
+			}
+			flags.AggregateByTag = splitList(aggregateTags)
+			if flags.CredentialsMode, err = parseFileMode("--credentialsMode", credsMode, 0o600); err != nil {
+				return err
+			}
+			if flags.ConnectionsMode, err = parseFileMode("--connectionsMode", connMode, 0o600); err != nil {
+				return err
+			}
+			if flags.DirMode, err = parseFileMode("--dirMode", dirMode, 0o700); err != nil {
+				return err
+			}
 
 			log := logger.New(flags.LogFormat)
 
-			if err := applyFlagDefaults(log, &flags, targetRegions, skipOUs); err != nil {
+			if err := applyFlagDefaults(log, &flags, targetRegions, includeOUs, skipOUs); err != nil {
+				return err
+			}
+			if err := validateAssumeRoleArn(flags.AssumeRoleArn, flags.DefaultRegion); err != nil {
 				return err
 			}
 
@@ -72,27 +185,56 @@ func NewRootCmd(run func(ctx context.Context, log *slog.Logger, flags *Flags) er
 		},
 	}
 
-	cmd.Flags().StringVar(&flags.RoleName, "role", "", "AWS Role to use in AWS config credentials")
+	cmd.Flags().StringVar(&flags.RoleName, "role", "", "AWS Role to use in AWS config credentials, or with --ssoStartURL the IAM Identity Center permission set. Required unless --ssoPermissionSets is set")
+	cmd.Flags().StringVar(&flags.RolePath, "rolePath", "", "IAM path of --role, e.g. /security/")
+	cmd.Flags().StringArrayVar(&rawRoleRules, "roleRule", nil, `Role of the accounts under an OU, as ou:<OU ID>=<role>, or with a tag value, as tag:<key>=<value>=<role> (repeatable, first match wins). <value> may use "*" and "?" globs, and <role> is [/path/]name, or /path/ alone to keep --role's name, e.g. --roleRule="ou:ou-ab12-cdef3456=/security/steampipe-reader". Accounts matching no rule use --role and --rolePath`)
 	cmd.Flags().StringVar(&flags.CredentialSource, "credential", "Environment", "AWS Credential source. Valid values are: Ec2InstanceMetadata, Environment, EcsContainer")
+	cmd.Flags().StringVar(&flags.SourceProfile, "sourceProfile", "", "AWS profile to assume the role with, written as source_profile instead of --credential's credential_source, e.g. an SSO profile")
+	cmd.Flags().StringVar(&flags.SSOStartURL, "ssoStartURL", "", "IAM Identity Center start URL: generate SSO profiles for the --role permission set instead of assume-role profiles. Written to the AWS config file")
+	cmd.Flags().StringVar(&flags.SSORegion, "ssoRegion", "", "IAM Identity Center region, --region if unset")
+	cmd.Flags().StringVar(&flags.SSOSession, "ssoSession", "steampipe", "Name of the sso-session the generated SSO profiles share")
+	cmd.Flags().StringVar(&permSets, "ssoPermissionSets", "", "Instead of --role, IAM Identity Center permission sets in order of preference, e.g. ReadOnlyAccess,ViewOnlyAccess: each account gets the first one provisioned to it, and accounts with none are skipped")
+	cmd.Flags().StringVar(&flags.ExternalID, "externalID", "", "External ID to assume every account's role with")
+	cmd.Flags().IntVar(&flags.DurationSeconds, "durationSeconds", 0, "Duration of every account's role session, in seconds, from 900 to 43200. Defaults to the role's own")
+	cmd.Flags().StringVar(&flags.RoleSessionName, "roleSessionName", "", "Name of every account's role session. Defaults to steampipe")
+	cmd.Flags().StringVar(&flags.MFASerial, "mfaSerial", "", "ARN or serial number of the MFA device to authenticate with before assuming every account's role")
+	cmd.Flags().StringVar(&flags.OverrideTagPrefix, "overrideTagPrefix", "steampipe:", `Prefix of the account tags overriding settings for their account, e.g. "steampipe:regions" or "steampipe:external_id", or leaving it out with "steampipe:skip" set to true. Empty disables them`)
 	cmd.Flags().StringVar(&flags.CredentialPath, "path", "", "AWS Credentials file path")
 	cmd.Flags().StringVar(&flags.ConnectionsPath, "connections", "", "Steampipe AWS connections file path")
 	cmd.Flags().StringVar(&flags.ImportSchema, "schema", "enabled", "AWS Connection import schema. Valid values are: enabled, disabled")
 	cmd.Flags().StringVar(&flags.DefaultRegion, "region", "", "AWS Connection default region")
-	cmd.Flags().StringVar(&targetRegions, "regions", "all", "AWS Connection target regions")
+	cmd.Flags().StringVar(&targetRegions, "regions", "all", `AWS Connection target regions: all, or comma-separated regions and patterns, e.g. "eu-*,us-east-1". Prefix one with "!" to exclude it, e.g. "*,!ap-east-1"`)
+	cmd.Flags().StringVar(&flags.DiscoverRegions, "discoverRegions", "", "Set each account's regions to those enabled in it that match --regions, listed with the organization credentials (organization: the management account or an Account Management delegated administrator) or by assuming each account's role (member-role)")
 	cmd.Flags().StringVar(&flags.AssumeRoleArn, "assume", "", "AWS Role to assume for getting Organization accounts")
 	cmd.Flags().StringVar(&flags.TemplatePath, "template", "", "Custom connections template path")
 	cmd.Flags().StringVar(&flags.LogFormat, "log", "default", "Log format: default, json")
-	cmd.Flags().StringVar(&skipOUs, "skipOUs", "", "AWS OU IDs to skip from account connections")
+	cmd.Flags().StringVar(&includeOUs, "includeOUs", "", "AWS OU IDs to restrict account connections to, including their nested OUs")
+	cmd.Flags().StringVar(&skipOUs, "skipOUs", "", "AWS OU IDs to skip from account connections, including their nested OUs. Takes precedence over --includeOUs")
+	cmd.Flags().StringVar(&flags.TagFilter, "filter", "", `Only include accounts whose tags match this expression, e.g. --filter="steampipe=enabled AND NOT env=sandbox". Supports key=value, key!=value, "key exists", AND/OR/NOT, parentheses, and * and ? globs in values`)
+	cmd.Flags().StringArrayVar(&rawInclude, "includeAccounts", nil, `Only include accounts whose ID, name or normalized name matches one of these comma-separated patterns (repeatable). Patterns are globs ("*", "?"), or regular expressions when prefixed with "re:", in which case the whole occurrence is one expression`)
+	cmd.Flags().StringArrayVar(&rawExclude, "excludeAccounts", nil, `Exclude accounts whose ID, name or normalized name matches one of these comma-separated patterns (repeatable), same syntax as --includeAccounts. Takes precedence over --includeAccounts`)
+	cmd.Flags().StringVar(&flags.NameCollision, "nameCollision", generator.NameCollisionFail, "How to handle accounts whose normalized names collide. Valid values are: "+strings.Join(generator.NameCollisionStrategies, ", "))
+	cmd.Flags().StringVar(&flags.NamingScheme, "naming", "name", `How each account's profile and connection name is built: name, id, name-id, tag:<key> (falls back to name), or a Go template over the account fields, e.g. "{{ .Name }}_{{ .ID }}"`)
+	cmd.Flags().StringVar(&aggregateTags, "aggregateByTag", "", "Tag keys to generate one aggregator connection per distinct value for, e.g. team,env")
+	cmd.Flags().BoolVar(&flags.AggregateByOU, "aggregateByOU", false, "Generate one aggregator connection per organizational unit, with every account in its subtree")
+	cmd.Flags().StringVar(&flags.AggregatorPrefix, "aggregatorPrefix", "aws_", "Name prefix for aggregator connections generated by --aggregateByTag and --aggregateByOU")
+	cmd.Flags().BoolVar(&flags.MergeCredentials, "mergeCredentials", false, "Merge generated profiles into a managed block of the existing credentials file instead of overwriting it")
+	cmd.Flags().BoolVar(&flags.MergeConnections, "mergeConnections", false, "Merge generated connections into a managed block of the existing connections file instead of overwriting it")
+	cmd.Flags().StringVar(&flags.ProfileFiles, "profileFiles", "credentials", "AWS files to write the generated profiles to, in the --path directory. Valid values are: credentials, config, both")
+	cmd.Flags().BoolVar(&flags.ProfileRegion, "profileRegion", false, "Set each profile's region to its connection's default region in the AWS config file")
+	cmd.Flags().BoolVar(&flags.MergeConfig, "mergeConfig", false, "Merge generated profiles into a managed block of the existing AWS config file instead of overwriting it")
+	cmd.Flags().BoolVar(&flags.DryRun, "dryRun", false, "Print a diff of what would change in the credentials and connections files, and a summary of changed accounts and aggregators, instead of writing them. Exits with code 2 if there are changes")
+	cmd.Flags().IntVar(&flags.Backups, "backups", defaultBackups, "Number of timestamped backups of each generated file to keep, restorable with the restore command. 0 disables backups")
+	cmd.Flags().StringVar(&credsMode, "credentialsMode", "0600", "Octal permissions of the AWS credentials file when created. An existing file keeps its permissions")
+	cmd.Flags().StringVar(&connMode, "connectionsMode", "0600", "Octal permissions of the Steampipe connections file when created. An existing file keeps its permissions")
+	cmd.Flags().StringVar(&dirMode, "dirMode", "0700", "Octal permissions of the credentials and connections directories when created")
 	cmd.Flags().StringArrayVar(&rawTagSplit, "tagSplit", nil, `Per-tag delimiter character(s) to split a multi-value tag on, as key=delimiter[,delimiter...] (repeatable), e.g. --tagSplit="team=:,-" splits the "team" tag on ':' or '-'. Parsed on the first '=' only, so delimiters may include '=' itself.`)
 
-	if err := cmd.MarkFlagRequired("role"); err != nil {
-		panic(err)
-	}
-
 	cmd.Version = fmt.Sprintf("%s (commit %s, built %s)", Version, Commit, Date)
 	cmd.SetVersionTemplate("steampipe-config-generator {{.Version}}\n")
 
 	cmd.AddCommand(NewVersionCmd())
+	cmd.AddCommand(NewRestoreCmd())
 
 	return cmd
 }
@@ -119,8 +261,89 @@ func parseTagSplit(raw []string) (map[string]string, error) {
 	return tagSplit, nil
 }
 
+// parseRoleRules parses each --roleRule occurrence: ou:<OU ID>=<role> or
+// tag:<key>=<value>=<role>. The role is taken after the last "=", since a tag value may
+// contain "=" (a valid AWS tag character) but a role name in a rule may not, and the tag key
+// before the first "=".
+func parseRoleRules(raw []string) ([]RoleRule, error) {
+	var rules []RoleRule
+	for _, entry := range raw {
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("--roleRule %q must be formatted as ou:<OU ID>=<role> or tag:<key>=<value>=<role>", entry)
+		}
+		match, role := entry[:i], entry[i+1:]
+		if role == "" {
+			return nil, fmt.Errorf("--roleRule %q has no role", entry)
+		}
+
+		var rule RoleRule
+		if slash := strings.LastIndex(role, "/"); slash >= 0 {
+			rule.RolePath, rule.RoleName = role[:slash+1], role[slash+1:]
+		} else {
+			rule.RoleName = role
+		}
+
+		if ou, ok := strings.CutPrefix(match, "ou:"); ok && ou != "" {
+			rule.OU = ou
+		} else if tag, ok := strings.CutPrefix(match, "tag:"); ok {
+			key, value, ok := strings.Cut(tag, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("--roleRule %q must be formatted as tag:<key>=<value>=<role>", entry)
+			}
+			rule.TagKey, rule.TagValue = key, value
+		} else {
+			return nil, fmt.Errorf("--roleRule %q must start with ou:<OU ID> or tag:<key>=<value>", entry)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseFileMode parses an octal permissions flag value such as "0600", which must grant the
+// owner at least the ownerPerm permissions (e.g. 0o700 for a directory it writes into).
+func parseFileMode(flag, value string, ownerPerm fs.FileMode) (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("%s %q must be octal permissions between 0000 and 0777", flag, value)
+	}
+	if fs.FileMode(mode)&ownerPerm != ownerPerm {
+		return 0, fmt.Errorf("%s %q must grant the owner at least %#o", flag, value, ownerPerm)
+	}
+	return fs.FileMode(mode), nil
+}
+
+// parseAccountPatterns flattens the --includeAccounts/--excludeAccounts occurrences into one
+// pattern list. Each occurrence is a comma-separated list of globs, except one starting with
+// "re:", which is taken verbatim as a single regular expression so that its own commas (e.g.
+// "re:^a{1,3}$") aren't split.
+func parseAccountPatterns(raw []string) []string {
+	var patterns []string
+	for _, entry := range raw {
+		if strings.HasPrefix(entry, "re:") {
+			patterns = append(patterns, entry)
+			continue
+		}
+		patterns = append(patterns, splitList(entry)...)
+	}
+	return patterns
+}
+
 func validateFlagValues(flags *Flags) error {
-	if !slices.Contains(validCredentialSources, flags.CredentialSource) {
+	switch {
+	case flags.RoleName == "" && len(flags.PermissionSets) == 0:
+		return fmt.Errorf("--role is required, unless --ssoPermissionSets is set")
+	case flags.RoleName != "" && len(flags.PermissionSets) > 0:
+		return fmt.Errorf("--role and --ssoPermissionSets are mutually exclusive")
+	case len(flags.PermissionSets) > 0 && flags.SSOStartURL == "":
+		return fmt.Errorf("--ssoPermissionSets requires --ssoStartURL")
+	case flags.SourceProfile != "" && flags.CredentialSource != "":
+		return fmt.Errorf("--credential and --sourceProfile are mutually exclusive")
+	case flags.SSOStartURL != "" && (flags.CredentialSource != "" || flags.SourceProfile != ""):
+		return fmt.Errorf("--ssoStartURL is mutually exclusive with --credential and --sourceProfile")
+	case flags.SSOStartURL != "" && flags.WritesCredentials():
+		return fmt.Errorf("--ssoStartURL profiles can only be written to the AWS config file, use --profileFiles config")
+	case flags.SourceProfile == "" && flags.SSOStartURL == "" && !slices.Contains(validCredentialSources, flags.CredentialSource):
 		return fmt.Errorf("--credential flag doesn't contain a valid value")
 	}
 	if !slices.Contains(validImportSchemas, flags.ImportSchema) {
@@ -129,26 +352,52 @@ func validateFlagValues(flags *Flags) error {
 	if !slices.Contains(validLogFormats, flags.LogFormat) {
 		return fmt.Errorf("--log unknown value. Valid values are: default, json")
 	}
+	if !slices.Contains(generator.NameCollisionStrategies, flags.NameCollision) {
+		return fmt.Errorf("--nameCollision unknown value. Valid values are: %s", strings.Join(generator.NameCollisionStrategies, ", "))
+	}
+	if !slices.Contains(validProfileFiles, flags.ProfileFiles) {
+		return fmt.Errorf("--profileFiles unknown value. Valid values are: %s", strings.Join(validProfileFiles, ", "))
+	}
+	if flags.DiscoverRegions != "" && !slices.Contains(validDiscoverRegions, flags.DiscoverRegions) {
+		return fmt.Errorf("--discoverRegions unknown value. Valid values are: %s", strings.Join(validDiscoverRegions, ", "))
+	}
+	if flags.DiscoverRegions == "member-role" && flags.SSOStartURL != "" {
+		return fmt.Errorf("--discoverRegions member-role needs a role to assume, which --ssoStartURL profiles don't have")
+	}
+	if flags.Backups < 0 {
+		return fmt.Errorf("--backups must not be negative")
+	}
 	return nil
 }
 
-// applyFlagDefaults fills in the defaults and derived fields that depend on the environment
-// (home directory, AWS_REGION) or on other flags (regions, skipOUs).
-func applyFlagDefaults(log *slog.Logger, flags *Flags, targetRegions, skipOUs string) error {
-	if flags.CredentialPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("getting user's home directory: %w", err)
-		}
-		flags.CredentialPath = filepath.Join(homeDir, ".aws/")
+// roleArnPattern matches an IAM role ARN, capturing its partition.
+var roleArnPattern = regexp.MustCompile(`^arn:([^:]+):iam::\d{12}:role/[\w+=,.@/-]+$`)
+
+// validateAssumeRoleArn checks that arn, if set, is an IAM role ARN in the partition of region,
+// the only one its credentials can be used in.
+func validateAssumeRoleArn(arn, region string) error {
+	if arn == "" {
+		return nil
+	}
+	match := roleArnPattern.FindStringSubmatch(arn)
+	if match == nil {
+		return fmt.Errorf("--assume %q must be an IAM role ARN, e.g. arn:aws:iam::123456789012:role/my-role", arn)
+	}
+	partition, ok := internalaws.ARNPartition(arn)
+	if !ok {
+		return fmt.Errorf("--assume %q has unknown partition %q. Valid partitions are: %s", arn, match[1], strings.Join(internalaws.Partitions, ", "))
 	}
+	if want := internalaws.PartitionForRegion(region); partition != want {
+		return fmt.Errorf("--assume %q is in partition %q, but region %s is in %q", arn, partition, region, want)
+	}
+	return nil
+}
 
-	if flags.ConnectionsPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("getting user's home directory: %w", err)
-		}
-		flags.ConnectionsPath = filepath.Join(homeDir, ".steampipe/config/")
+// applyFlagDefaults fills in the defaults and derived fields that depend on the environment
+// (home directory, AWS_REGION) or on other flags (regions, includeOUs, skipOUs).
+func applyFlagDefaults(log *slog.Logger, flags *Flags, targetRegions, includeOUs, skipOUs string) error {
+	if err := applyPathDefaults(&flags.CredentialPath, &flags.ConnectionsPath); err != nil {
+		return err
 	}
 
 	if flags.DefaultRegion == "" {
@@ -161,15 +410,81 @@ func applyFlagDefaults(log *slog.Logger, flags *Flags, targetRegions, skipOUs st
 		}
 	}
 
-	if targetRegions == "all" {
-		flags.TargetRegions = []string{"*"}
-	} else {
-		flags.TargetRegions = strings.Split(targetRegions, ",")
+	err := internalaws.ValidateRegion(internalaws.PartitionForRegion(flags.DefaultRegion), flags.DefaultRegion)
+	if err := warnUnknownRegion(log, "--region", err); err != nil {
+		return fmt.Errorf("--region: %w", err)
 	}
+
+	regions, err := parseTargetRegions(log, targetRegions, internalaws.PartitionForRegion(flags.DefaultRegion))
+	if err != nil {
+		return fmt.Errorf("--regions: %w", err)
+	}
+	flags.TargetRegions = regions
 	log.Debug("regions", "value", flags.TargetRegions)
 
-	flags.SkipOUs = strings.Split(skipOUs, ",")
+	flags.IncludeOUs = splitList(includeOUs)
+	log.Debug("includeOUs", "value", flags.IncludeOUs)
+
+	flags.SkipOUs = splitList(skipOUs)
 	log.Debug("skipOUs", "value", flags.SkipOUs)
 
 	return nil
 }
+
+// parseTargetRegions parses a --regions value: "all", or a comma-separated list of regions and
+// patterns (see internalaws.ParseRegions), e.g. "eu-*,us-east-1" or "*,!ap-east-1". Regions
+// unknown to this build are kept with a warning.
+func parseTargetRegions(log *slog.Logger, value, partition string) ([]string, error) {
+	regions, unknown, err := internalaws.ParseRegions(partition, splitList(value))
+	if err != nil {
+		return nil, err
+	}
+	if len(unknown) > 0 {
+		log.Warn("--regions: regions unknown to this build, using them as is", "partition", partition, "regions", unknown)
+	}
+	return regions, nil
+}
+
+// warnUnknownRegion logs err as a warning about the region set by flag, returning nil, if it
+// wraps internalaws.ErrUnknownRegion: a well-formed region newer than this build, most likely.
+// It returns any other err as is.
+func warnUnknownRegion(log *slog.Logger, flag string, err error) error {
+	if !errors.Is(err, internalaws.ErrUnknownRegion) {
+		return err
+	}
+	log.Warn(flag + ": " + err.Error())
+	return nil
+}
+
+// applyPathDefaults defaults an empty credentials path to ~/.aws and an empty connections
+// path to ~/.steampipe/config.
+func applyPathDefaults(credentialPath, connectionsPath *string) error {
+	if *credentialPath == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("getting user's home directory: %w", err)
+		}
+		*credentialPath = filepath.Join(homeDir, ".aws/")
+	}
+
+	if *connectionsPath == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("getting user's home directory: %w", err)
+		}
+		*connectionsPath = filepath.Join(homeDir, ".steampipe/config/")
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, trimming whitespace and dropping empty
+// entries, so an unset flag yields nil rather than a single empty-string entry.
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item := strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
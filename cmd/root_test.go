@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"log/slog"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/unicrons/steampipe-config-generator/cmd"
+	"github.com/unicrons/steampipe-config-generator/generator"
 )
 
 type runFunc func(ctx context.Context, log *slog.Logger, flags *cmd.Flags) error
@@ -43,7 +46,29 @@ func TestNewRootCmd_HappyPath(t *testing.T) {
 		"--regions", "eu-west-1,us-east-1",
 		"--assume", "arn:aws:iam::123456789012:role/assume-me",
 		"--log", "json",
+		"--includeOUs", "ou-0",
 		"--skipOUs", "ou-1,ou-2",
+		"--filter", "steampipe=enabled",
+		"--naming", "tag:steampipe-alias",
+		"--aggregateByTag", "team,env",
+		"--aggregateByOU",
+		"--aggregatorPrefix", "agg_",
+		"--mergeCredentials",
+		"--mergeConnections",
+		"--backups", "2",
+		"--dryRun",
+		"--profileFiles", "both",
+		"--profileRegion",
+		"--mergeConfig",
+		"--externalID", "tenant-42",
+		"--durationSeconds", "3600",
+		"--roleSessionName", "audit",
+		"--mfaSerial", "arn:aws:iam::123456789012:mfa/alice",
+		"--overrideTagPrefix", "sp:",
+		"--discoverRegions", "organization",
+		"--credentialsMode", "0640",
+		"--connectionsMode", "600",
+		"--dirMode", "0750",
 	)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -69,6 +94,48 @@ func TestNewRootCmd_HappyPath(t *testing.T) {
 	if len(got.SkipOUs) != len(wantSkipOUs) || got.SkipOUs[0] != wantSkipOUs[0] || got.SkipOUs[1] != wantSkipOUs[1] {
 		t.Errorf("SkipOUs = %v, want %v", got.SkipOUs, wantSkipOUs)
 	}
+	if len(got.IncludeOUs) != 1 || got.IncludeOUs[0] != "ou-0" {
+		t.Errorf("IncludeOUs = %v, want [ou-0]", got.IncludeOUs)
+	}
+	if got.TagFilter != "steampipe=enabled" {
+		t.Errorf("TagFilter = %q, want %q", got.TagFilter, "steampipe=enabled")
+	}
+	if got.NamingScheme != "tag:steampipe-alias" {
+		t.Errorf("NamingScheme = %q, want %q", got.NamingScheme, "tag:steampipe-alias")
+	}
+	if want := []string{"team", "env"}; !slices.Equal(got.AggregateByTag, want) {
+		t.Errorf("AggregateByTag = %q, want %q", got.AggregateByTag, want)
+	}
+	if !got.AggregateByOU || got.AggregatorPrefix != "agg_" {
+		t.Errorf("AggregateByOU, AggregatorPrefix = %v, %q, want true, %q", got.AggregateByOU, got.AggregatorPrefix, "agg_")
+	}
+	if !got.MergeCredentials || !got.MergeConnections {
+		t.Errorf("MergeCredentials, MergeConnections = %v, %v, want both true", got.MergeCredentials, got.MergeConnections)
+	}
+	if got.Backups != 2 {
+		t.Errorf("Backups = %d, want 2", got.Backups)
+	}
+	if !got.DryRun {
+		t.Error("DryRun = false, want true")
+	}
+	if got.ProfileFiles != "both" || !got.ProfileRegion || !got.MergeConfig {
+		t.Errorf("ProfileFiles, ProfileRegion, MergeConfig = %q, %v, %v, want both, true, true", got.ProfileFiles, got.ProfileRegion, got.MergeConfig)
+	}
+	if !got.WritesCredentials() || !got.WritesConfig() {
+		t.Errorf("WritesCredentials(), WritesConfig() = %v, %v, want both true", got.WritesCredentials(), got.WritesConfig())
+	}
+	if got.ExternalID != "tenant-42" || got.DurationSeconds != 3600 || got.RoleSessionName != "audit" || got.MFASerial != "arn:aws:iam::123456789012:mfa/alice" {
+		t.Errorf("ExternalID, DurationSeconds, RoleSessionName, MFASerial = %q, %d, %q, %q", got.ExternalID, got.DurationSeconds, got.RoleSessionName, got.MFASerial)
+	}
+	if got.OverrideTagPrefix != "sp:" {
+		t.Errorf("OverrideTagPrefix = %q, want %q", got.OverrideTagPrefix, "sp:")
+	}
+	if got.DiscoverRegions != "organization" {
+		t.Errorf("DiscoverRegions = %q, want %q", got.DiscoverRegions, "organization")
+	}
+	if got.CredentialsMode != 0o640 || got.ConnectionsMode != 0o600 || got.DirMode != 0o750 {
+		t.Errorf("CredentialsMode, ConnectionsMode, DirMode = %v, %v, %v, want -rw-r-----, -rw-------, -rwxr-x---", got.CredentialsMode, got.ConnectionsMode, got.DirMode)
+	}
 }
 
 func TestNewRootCmd_HappyPath_Defaults(t *testing.T) {
@@ -92,9 +159,27 @@ func TestNewRootCmd_HappyPath_Defaults(t *testing.T) {
 	if got.LogFormat != "default" {
 		t.Errorf("LogFormat default = %q, want %q", got.LogFormat, "default")
 	}
+	if got.NameCollision != "fail" {
+		t.Errorf("NameCollision default = %q, want %q", got.NameCollision, "fail")
+	}
+	if got.Backups != 5 {
+		t.Errorf("Backups default = %d, want 5", got.Backups)
+	}
+	if got.OverrideTagPrefix != "steampipe:" {
+		t.Errorf("OverrideTagPrefix default = %q, want %q", got.OverrideTagPrefix, "steampipe:")
+	}
+	if !got.WritesCredentials() || got.WritesConfig() {
+		t.Errorf("WritesCredentials(), WritesConfig() defaults = %v, %v, want true, false", got.WritesCredentials(), got.WritesConfig())
+	}
+	if got.CredentialsMode != 0o600 || got.ConnectionsMode != 0o600 || got.DirMode != 0o700 {
+		t.Errorf("CredentialsMode, ConnectionsMode, DirMode defaults = %v, %v, %v, want -rw-------, -rw-------, -rwx------", got.CredentialsMode, got.ConnectionsMode, got.DirMode)
+	}
 	if len(got.TargetRegions) != 1 || got.TargetRegions[0] != "*" {
 		t.Errorf("TargetRegions default = %v, want [*]", got.TargetRegions)
 	}
+	if got.IncludeOUs != nil || got.SkipOUs != nil {
+		t.Errorf("IncludeOUs, SkipOUs defaults = %q, %q, want both nil", got.IncludeOUs, got.SkipOUs)
+	}
 }
 
 func TestNewRootCmd_TagSplit_Repeatable(t *testing.T) {
@@ -174,6 +259,222 @@ func TestNewRootCmd_TagSplit_MissingEquals(t *testing.T) {
 	}
 }
 
+func TestNewRootCmd_AccountPatterns(t *testing.T) {
+	var got *cmd.Flags
+	run := func(_ context.Context, _ *slog.Logger, f *cmd.Flags) error {
+		got = f
+		return nil
+	}
+
+	_, err := execute(t, run,
+		"--role", "my-role",
+		"--includeAccounts", "team_*",
+		"--excludeAccounts", "111111111111, log_archive",
+		"--excludeAccounts", "re:^break.{1,3}glass$",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []string{"team_*"}; !slices.Equal(got.IncludeAccounts, want) {
+		t.Errorf("IncludeAccounts = %q, want %q", got.IncludeAccounts, want)
+	}
+	// The regular expression's own comma must not split it into two patterns.
+	if want := []string{"111111111111", "log_archive", "re:^break.{1,3}glass$"}; !slices.Equal(got.ExcludeAccounts, want) {
+		t.Errorf("ExcludeAccounts = %q, want %q", got.ExcludeAccounts, want)
+	}
+}
+
+// --nameCollision accepts exactly the strategies the generator implements.
+func TestNewRootCmd_NameCollisionStrategies(t *testing.T) {
+	for _, strategy := range generator.NameCollisionStrategies {
+		var got *cmd.Flags
+		run := func(_ context.Context, _ *slog.Logger, flags *cmd.Flags) error {
+			got = flags
+			return nil
+		}
+
+		if _, err := execute(t, run, "--role", "x", "--nameCollision", strategy); err != nil {
+			t.Errorf("--nameCollision %s: unexpected error: %v", strategy, err)
+			continue
+		}
+		if got.NameCollision != strategy {
+			t.Errorf("NameCollision = %q, want %q", got.NameCollision, strategy)
+		}
+	}
+}
+
+// --sourceProfile replaces the default --credential instead of conflicting with it.
+func TestNewRootCmd_SourceProfile(t *testing.T) {
+	var got *cmd.Flags
+	run := func(_ context.Context, _ *slog.Logger, f *cmd.Flags) error {
+		got = f
+		return nil
+	}
+
+	_, err := execute(t, run, "--role", "my-role", "--sourceProfile", "sso-hub")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.SourceProfile != "sso-hub" || got.CredentialSource != "" {
+		t.Errorf("SourceProfile, CredentialSource = %q, %q, want %q, none", got.SourceProfile, got.CredentialSource, "sso-hub")
+	}
+}
+
+// --ssoStartURL drops the default --credential, and writes to the config file by default.
+func TestNewRootCmd_SSO(t *testing.T) {
+	var got *cmd.Flags
+	run := func(_ context.Context, _ *slog.Logger, f *cmd.Flags) error {
+		got = f
+		return nil
+	}
+
+	_, err := execute(t, run,
+		"--role", "ReadOnlyAccess",
+		"--ssoStartURL", "https://example.awsapps.com/start",
+		"--ssoRegion", "eu-west-1",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.SSOStartURL != "https://example.awsapps.com/start" || got.SSORegion != "eu-west-1" || got.SSOSession != "steampipe" {
+		t.Errorf("SSOStartURL, SSORegion, SSOSession = %q, %q, %q", got.SSOStartURL, got.SSORegion, got.SSOSession)
+	}
+	if got.CredentialSource != "" {
+		t.Errorf("CredentialSource = %q, want none", got.CredentialSource)
+	}
+	if got.ProfileFiles != "config" {
+		t.Errorf("ProfileFiles = %q, want %q", got.ProfileFiles, "config")
+	}
+}
+
+// --ssoPermissionSets replaces --role.
+func TestNewRootCmd_SSOPermissionSets(t *testing.T) {
+	var got *cmd.Flags
+	run := func(_ context.Context, _ *slog.Logger, f *cmd.Flags) error {
+		got = f
+		return nil
+	}
+
+	_, err := execute(t, run,
+		"--ssoStartURL", "https://example.awsapps.com/start",
+		"--ssoPermissionSets", "ReadOnlyAccess, ViewOnlyAccess",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"ReadOnlyAccess", "ViewOnlyAccess"}; !slices.Equal(got.PermissionSets, want) {
+		t.Errorf("PermissionSets = %q, want %q", got.PermissionSets, want)
+	}
+}
+
+// The role of a --roleRule is taken after its last "=", so a tag value may contain "=".
+func TestNewRootCmd_RoleRules(t *testing.T) {
+	var got *cmd.Flags
+	run := func(_ context.Context, _ *slog.Logger, f *cmd.Flags) error {
+		got = f
+		return nil
+	}
+
+	_, err := execute(t, run,
+		"--role", "steampipe",
+		"--rolePath", "/security/",
+		"--roleRule", "ou:ou-ab12-prod=/security/steampipe-reader",
+		"--roleRule", "tag:env=sandbox-*=sandbox-reader",
+		"--roleRule", "tag:owner=a=b=/readers/",
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.RolePath != "/security/" {
+		t.Errorf("RolePath = %q, want %q", got.RolePath, "/security/")
+	}
+	want := []cmd.RoleRule{
+		{OU: "ou-ab12-prod", RoleName: "steampipe-reader", RolePath: "/security/"},
+		{TagKey: "env", TagValue: "sandbox-*", RoleName: "sandbox-reader"},
+		{TagKey: "owner", TagValue: "a=b", RolePath: "/readers/"},
+	}
+	if !slices.Equal(got.RoleRules, want) {
+		t.Errorf("RoleRules = %+v, want %+v", got.RoleRules, want)
+	}
+}
+
+func TestNewRootCmd_Regions(t *testing.T) {
+	tests := []struct {
+		regions string
+		region  string
+		want    []string
+	}{
+		{regions: "all", region: "eu-west-1", want: []string{"*"}},
+		{regions: "eu-*, us-east-1", region: "eu-west-1", want: []string{"eu-*", "us-east-1"}},
+		{regions: "eu-west-*,!eu-west-3", region: "eu-west-1", want: []string{"eu-west-1", "eu-west-2"}},
+		{regions: "!us-gov-east-1", region: "us-gov-west-1", want: []string{"us-gov-west-1"}},
+		{regions: "*,!us-*,!eu-*,!ap-*,!ca-*", region: "eu-west-1", want: []string{"af-south-1", "il-central-1", "me-central-1", "me-south-1", "mx-central-1", "sa-east-1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.regions, func(t *testing.T) {
+			var got *cmd.Flags
+			run := func(_ context.Context, _ *slog.Logger, f *cmd.Flags) error {
+				got = f
+				return nil
+			}
+
+			if _, err := execute(t, run, "--role", "x", "--regions", tt.regions, "--region", tt.region); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(got.TargetRegions, tt.want) {
+				t.Errorf("TargetRegions = %q, want %q", got.TargetRegions, tt.want)
+			}
+		})
+	}
+}
+
+// A mistyped region fails the run, suggesting the region it was most likely meant to be.
+func TestNewRootCmd_RegionSuggestion(t *testing.T) {
+	run := func(context.Context, *slog.Logger, *cmd.Flags) error {
+		t.Fatal("run should not be called for an unknown region")
+		return nil
+	}
+
+	_, err := execute(t, run, "--role", "x", "--region", "eu-west-1", "--regions", "eu-wset-1")
+	if err == nil || !strings.Contains(err.Error(), `did you mean "eu-west-1"?`) {
+		t.Errorf("error = %v, want one suggesting eu-west-1", err)
+	}
+}
+
+// A well-formed region this build doesn't know, most likely a newer one, is kept with a warning.
+func TestNewRootCmd_UnknownRegion(t *testing.T) {
+	var got *cmd.Flags
+	run := func(_ context.Context, _ *slog.Logger, flags *cmd.Flags) error {
+		got = flags
+		return nil
+	}
+
+	if _, err := execute(t, run, "--role", "x", "--region", "eu-west-4", "--regions", "eu-west-1,eu-west-4"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.DefaultRegion != "eu-west-4" || !slices.Equal(got.TargetRegions, []string{"eu-west-1", "eu-west-4"}) {
+		t.Errorf("DefaultRegion, TargetRegions = %q, %q, want eu-west-4 and both regions", got.DefaultRegion, got.TargetRegions)
+	}
+}
+
+func TestNewRootCmd_AssumePartitions(t *testing.T) {
+	tests := map[string]string{
+		"arn:aws:iam::123456789012:role/assume-me":             "eu-west-1",
+		"arn:aws-us-gov:iam::123456789012:role/path/assume-me": "us-gov-west-1",
+		"arn:aws-cn:iam::123456789012:role/assume-me":          "cn-north-1",
+		"arn:aws-iso:iam::123456789012:role/assume-me":         "us-iso-east-1",
+	}
+	for arn, region := range tests {
+		t.Run(arn, func(t *testing.T) {
+			run := func(context.Context, *slog.Logger, *cmd.Flags) error { return nil }
+			if _, err := execute(t, run, "--role", "x", "--assume", arn, "--region", region); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
 func TestNewRootCmd_RoleRequired(t *testing.T) {
 	run := func(context.Context, *slog.Logger, *cmd.Flags) error {
 		t.Fatal("run should not be called when --role is missing")
@@ -203,6 +504,130 @@ func TestNewRootCmd_InvalidFlagValues(t *testing.T) {
 			name: "invalid log format",
 			args: []string{"--role", "x", "--log", "Bogus"},
 		},
+		{
+			name: "invalid name collision strategy",
+			args: []string{"--role", "x", "--nameCollision", "Bogus"},
+		},
+		{
+			name: "credential and source profile",
+			args: []string{"--role", "x", "--credential", "Environment", "--sourceProfile", "sso-hub"},
+		},
+		{
+			name: "SSO and credential",
+			args: []string{"--role", "x", "--ssoStartURL", "https://example.awsapps.com/start", "--credential", "Environment"},
+		},
+		{
+			name: "SSO and source profile",
+			args: []string{"--role", "x", "--ssoStartURL", "https://example.awsapps.com/start", "--sourceProfile", "sso-hub"},
+		},
+		{
+			name: "SSO profiles in the credentials file",
+			args: []string{"--role", "x", "--ssoStartURL", "https://example.awsapps.com/start", "--profileFiles", "both"},
+		},
+		{
+			name: "SSO permission sets and role",
+			args: []string{"--role", "x", "--ssoStartURL", "https://example.awsapps.com/start", "--ssoPermissionSets", "ReadOnlyAccess"},
+		},
+		{
+			name: "permission sets without SSO",
+			args: []string{"--ssoPermissionSets", "ReadOnlyAccess"},
+		},
+		{
+			name: "assume ARN that isn't a role",
+			args: []string{"--role", "x", "--assume", "arn:aws:iam::123456789012:user/alice"},
+		},
+		{
+			name: "assume ARN in an unknown partition",
+			args: []string{"--role", "x", "--assume", "arn:aws-moon:iam::123456789012:role/x", "--region", "us-east-1"},
+		},
+		{
+			name: "assume ARN in another partition than the region",
+			args: []string{"--role", "x", "--assume", "arn:aws:iam::123456789012:role/x", "--region", "us-gov-west-1"},
+		},
+		{
+			name: "role rule without a role",
+			args: []string{"--role", "x", "--roleRule", "ou:ou-ab12-prod"},
+		},
+		{
+			name: "role rule with an empty role",
+			args: []string{"--role", "x", "--roleRule", "ou:ou-ab12-prod="},
+		},
+		{
+			name: "role rule without an OU ID",
+			args: []string{"--role", "x", "--roleRule", "ou:=other"},
+		},
+		{
+			name: "role rule without a tag value",
+			args: []string{"--role", "x", "--roleRule", "tag:env=other"},
+		},
+		{
+			name: "role rule with an unknown matcher",
+			args: []string{"--role", "x", "--roleRule", "account:123456789012=other"},
+		},
+		{
+			name: "invalid regions",
+			args: []string{"--role", "x", "--regions", "eu-west-1,europe"},
+		},
+		{
+			name: "invalid region discovery",
+			args: []string{"--role", "x", "--discoverRegions", "Bogus"},
+		},
+		{
+			name: "member role region discovery with SSO",
+			args: []string{"--role", "x", "--ssoStartURL", "https://example.awsapps.com/start", "--discoverRegions", "member-role"},
+		},
+		{
+			name: "unknown region",
+			args: []string{"--role", "x", "--region", "eu-wset-1"},
+		},
+		{
+			name: "unknown target region",
+			args: []string{"--role", "x", "--region", "eu-west-1", "--regions", "eu-west-1,eu-wset-1"},
+		},
+		{
+			name: "target region in another partition",
+			args: []string{"--role", "x", "--region", "us-gov-west-1", "--regions", "eu-west-1"},
+		},
+		{
+			name: "region pattern matching nothing",
+			args: []string{"--role", "x", "--region", "eu-west-1", "--regions", "xx-*"},
+		},
+		{
+			name: "unknown excluded region",
+			args: []string{"--role", "x", "--region", "eu-west-1", "--regions", "*,!eu-wset-1"},
+		},
+		{
+			name: "regions excluding every region",
+			args: []string{"--role", "x", "--region", "eu-west-1", "--regions", "eu-west-1,!eu-*"},
+		},
+		{
+			name: "empty regions",
+			args: []string{"--role", "x", "--region", "eu-west-1", "--regions", ","},
+		},
+		{
+			name: "negative backups",
+			args: []string{"--role", "x", "--backups", "-1"},
+		},
+		{
+			name: "invalid profile files",
+			args: []string{"--role", "x", "--profileFiles", "Bogus"},
+		},
+		{
+			name: "non-octal mode",
+			args: []string{"--role", "x", "--credentialsMode", "0680"},
+		},
+		{
+			name: "mode out of range",
+			args: []string{"--role", "x", "--connectionsMode", "01777"},
+		},
+		{
+			name: "file mode the owner can't write",
+			args: []string{"--role", "x", "--credentialsMode", "0400"},
+		},
+		{
+			name: "directory mode the owner can't traverse",
+			args: []string{"--role", "x", "--dirMode", "0600"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,10 +1,13 @@
 package generator
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"slices"
 	"strings"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
 )
 
 // validTagSplitDelimiters is the subset of AWS's supported tag character set that may be used
@@ -80,14 +83,24 @@ func splitTagValue(key, value string, tagSplit map[string]string) []string {
 }
 
 func (g *generator) Accounts(ctx context.Context) ([]Account, error) {
-	orgAccounts, err := g.client.ListAccounts(ctx)
+	orgAccounts, units, err := g.client.ListAccounts(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("fetching organization accounts: %w", err)
 	}
+	if err := g.validateOUs(units, g.roles.ouIDs()); err != nil {
+		return nil, err
+	}
+
+	targetRegions := sortedRegions(g.opts.TargetRegions)
 
 	accounts := make([]Account, 0, len(orgAccounts))
 	for _, acc := range orgAccounts {
-		if slices.Contains(g.opts.SkipOUs, acc.OU) {
+		if !g.inSelectedOUs(acc.OUPath) {
+			continue
+		}
+
+		name := normalizeAccountName(acc.Name)
+		if !g.filters.matchAccount(acc, name) {
 			continue
 		}
 
@@ -95,21 +108,113 @@ func (g *generator) Accounts(ctx context.Context) ([]Account, error) {
 		for key, value := range acc.Tags {
 			tags[key] = splitTagValue(key, value, g.opts.TagSplit)
 		}
+		if g.filters.tags != nil && !g.filters.tags.match(tags) {
+			continue
+		}
 
-		accounts = append(accounts, Account{
-			Name:             normalizeAccountName(acc.Name),
-			RoleARN:          fmt.Sprintf("arn:aws:iam::%s:role/%s", acc.ID, g.opts.RoleName),
+		partition := g.partition(acc.ARN)
+		var overrides connectionOverrides
+		if g.opts.OverrideTagPrefix != "" {
+			if overrides, err = tagConnectionOverrides(g.opts.OverrideTagPrefix, partition, acc.Tags); err != nil {
+				return nil, fmt.Errorf("account %s: %w", acc.ID, err)
+			}
+			if overrides.skip {
+				continue
+			}
+		}
+
+		ouPath := convertOUPath(acc.OUPath)
+		account := Account{
+			ID:               acc.ID,
+			Name:             name,
+			OriginalName:     acc.Name,
+			Email:            acc.Email,
+			ARN:              acc.ARN,
+			Partition:        partition,
+			JoinedTimestamp:  acc.JoinedTimestamp,
+			JoinedMethod:     acc.JoinedMethod,
 			CredentialSource: g.opts.CredentialSource,
-			ImportSchema:     g.opts.ImportSchema,
-			DefaultRegion:    g.opts.Region,
-			TargetRegions:    g.opts.TargetRegions,
+			SourceProfile:    g.opts.SourceProfile,
+			ImportSchema:     cmp.Or(overrides.importSchema, g.opts.ImportSchema),
+			DefaultRegion:    cmp.Or(overrides.defaultRegion, g.opts.Region),
+			TargetRegions:    targetRegions,
 			Tags:             tags,
-		})
+			OUPath:           ouPath,
+			UnknownRegions:   overrides.unknownRegions,
+		}
+		if overrides.targetRegions != nil {
+			account.TargetRegions = overrides.targetRegions
+		}
+		if len(ouPath) > 0 {
+			account.OU = ouPath[len(ouPath)-1]
+		}
+		if g.opts.SSOStartURL != "" {
+			account.SSOStartURL = g.opts.SSOStartURL
+			account.SSORegion = cmp.Or(g.opts.SSORegion, g.opts.Region)
+			account.SSOSession = cmp.Or(g.opts.SSOSessionName, DefaultSSOSessionName)
+			account.SSORoleName = g.opts.RoleName
+			account.Overrides = appliedOverrides(g.opts.OverrideTagPrefix, acc.Tags, connectionOverrideTags)
+		} else {
+			roleName, rolePath := g.roles.resolve(acc.OUPath, tags)
+			account.RoleARN = fmt.Sprintf("arn:%s:iam::%s:role%s%s", account.Partition, acc.ID, rolePath, roleName)
+			if account.AssumeRoleOptions, err = accountAssumeRole(g.opts, acc); err != nil {
+				return nil, err
+			}
+			account.Overrides = appliedOverrides(g.opts.OverrideTagPrefix, acc.Tags, connectionOverrideTags, assumeRoleOverrideTags)
+		}
+		if account.Name, err = g.namer(account); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
 	}
 
+	// Only look up the permission sets of the accounts left after filtering, and before
+	// resolving name collisions: an account left out can't collide.
+	if len(g.opts.PermissionSets) > 0 {
+		if accounts, err = g.assignPermissionSets(ctx, accounts); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.opts.RegionDiscovery != "" {
+		if err := g.discoverRegions(ctx, accounts); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := resolveNameCollisions(accounts, g.opts.NameCollision); err != nil {
+		return nil, err
+	}
+	if err := checkSourceProfile(accounts, g.opts.SourceProfile); err != nil {
+		return nil, err
+	}
+
+	// AWS Organizations returns accounts in no documented order, so sort them: the same
+	// organization state must always render byte-identical files.
+	sortAccounts(accounts)
+
 	return accounts, nil
 }
 
-func normalizeAccountName(name string) string {
-	return strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(name, " ", "_"), "-", "_"))
+// partition returns the partition of the account with the Organizations ARN arn, falling back
+// to the partition of the AWS config's region, then of Options.Region, if arn has none.
+func (g *generator) partition(arn string) string {
+	if partition, ok := internalaws.ARNPartition(arn); ok {
+		return partition
+	}
+	return internalaws.PartitionForRegion(cmp.Or(g.region, g.opts.Region))
+}
+
+// sortAccounts sorts accounts in place by Name, then ID.
+func sortAccounts(accounts []Account) {
+	slices.SortFunc(accounts, func(a, b Account) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+// sortedRegions returns a sorted, deduplicated copy of regions.
+func sortedRegions(regions []string) []string {
+	sorted := slices.Clone(regions)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
 }
@@ -3,7 +3,9 @@ package generator
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
+	"time"
 
 	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
 )
@@ -12,34 +14,77 @@ import (
 // happen in these tests.
 type fakeOrganizationsClient struct {
 	accounts []internalaws.Account
-	err      error
+	// units lists OUs beyond those found in the accounts' OU paths, e.g. empty ones.
+	units []internalaws.OrganizationalUnit
+	err   error
+
+	listCalls int
 }
 
-func (f *fakeOrganizationsClient) ListAccounts(ctx context.Context) ([]internalaws.Account, error) {
+// ListAccounts returns the fake accounts, and every OU found in their OU paths or in units.
+func (f *fakeOrganizationsClient) ListAccounts(ctx context.Context) ([]internalaws.Account, []internalaws.OrganizationalUnit, error) {
+	f.listCalls++
 	if f.err != nil {
-		return nil, f.err
+		return nil, nil, f.err
+	}
+
+	units := slices.Clone(f.units)
+	for _, acc := range f.accounts {
+		for _, ou := range acc.OUPath {
+			if !slices.Contains(units, ou) {
+				units = append(units, ou)
+			}
+		}
+	}
+	return f.accounts, units, nil
+}
+
+// newTestGenerator returns the generator New would build for opts, listing accounts with
+// client.
+func newTestGenerator(t *testing.T, client OrganizationsClient, opts Options) *generator {
+	t.Helper()
+	g, err := newGenerator(opts)
+	if err != nil {
+		t.Fatalf("newGenerator() unexpected error: %v", err)
+	}
+	g.client = client
+	return g
+}
+
+// ouPath builds an OU path from IDs, root first.
+func ouPath(ids ...string) []internalaws.OrganizationalUnit {
+	path := make([]internalaws.OrganizationalUnit, 0, len(ids))
+	for _, id := range ids {
+		path = append(path, internalaws.OrganizationalUnit{ID: id})
 	}
-	return f.accounts, nil
+	return path
 }
 
 func TestGenerator_Accounts(t *testing.T) {
 	client := &fakeOrganizationsClient{
 		accounts: []internalaws.Account{
-			{ID: "111111111111", Name: "Team Foo", OU: "ou-root", Tags: map[string]string{"team": "foo"}},
-			{ID: "222222222222", Name: "team-bar", OU: "ou-sandbox", Tags: map[string]string{"team": "bar"}},
-		},
-	}
-	g := &generator{
-		client: client,
-		opts: Options{
-			RoleName:         "my-role",
-			CredentialSource: "Environment",
-			ImportSchema:     "enabled",
-			Region:           "us-east-1",
-			TargetRegions:    []string{"*"},
-			SkipOUs:          []string{"ou-sandbox"},
+			{
+				ID:              "111111111111",
+				Name:            "Team Foo",
+				Email:           "foo@example.com",
+				ARN:             "arn:aws:organizations::999999999999:account/o-example/111111111111",
+				JoinedTimestamp: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				JoinedMethod:    "INVITED",
+				OU:              "r-root",
+				OUPath:          []internalaws.OrganizationalUnit{{ID: "r-root", Name: "Root"}},
+				Tags:            map[string]string{"team": "foo"},
+			},
+			{ID: "222222222222", Name: "team-bar", OU: "ou-sandbox", OUPath: ouPath("r-root", "ou-sandbox"), Tags: map[string]string{"team": "bar"}},
 		},
 	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:         "my-role",
+		CredentialSource: "Environment",
+		ImportSchema:     "enabled",
+		Region:           "us-east-1",
+		TargetRegions:    []string{"*"},
+		SkipOUs:          []string{"ou-sandbox"},
+	})
 
 	accounts, err := g.Accounts(t.Context())
 	if err != nil {
@@ -57,15 +102,51 @@ func TestGenerator_Accounts(t *testing.T) {
 	if got.RoleARN != "arn:aws:iam::111111111111:role/my-role" {
 		t.Errorf("RoleARN = %q", got.RoleARN)
 	}
+	if got.ID != "111111111111" || got.OriginalName != "Team Foo" || got.Email != "foo@example.com" || got.JoinedMethod != "INVITED" {
+		t.Errorf("ID, OriginalName, Email, JoinedMethod = %q, %q, %q, %q", got.ID, got.OriginalName, got.Email, got.JoinedMethod)
+	}
+	if got.ARN != "arn:aws:organizations::999999999999:account/o-example/111111111111" {
+		t.Errorf("ARN = %q", got.ARN)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !got.JoinedTimestamp.Equal(want) {
+		t.Errorf("JoinedTimestamp = %v, want %v", got.JoinedTimestamp, want)
+	}
+	if want := (OrganizationalUnit{ID: "r-root", Name: "Root"}); got.OU != want {
+		t.Errorf("OU = %+v, want %+v", got.OU, want)
+	}
 	if want := []string{"foo"}; len(got.Tags["team"]) != 1 || got.Tags["team"][0] != want[0] {
 		t.Errorf("Tags[team] = %v, want %v", got.Tags["team"], want)
 	}
 }
 
+// SkipOUs excludes a skipped OU's whole subtree, not just the accounts directly inside it.
+func TestGenerator_Accounts_SkipOUsNested(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "prod-a", OU: "ou-prod", OUPath: ouPath("r-root", "ou-workloads", "ou-prod")},
+			{ID: "222222222222", Name: "sandbox-a", OU: "ou-team-x", OUPath: ouPath("r-root", "ou-sandbox", "ou-team-x")},
+			{ID: "333333333333", Name: "sandbox-b", OU: "ou-deep", OUPath: ouPath("r-root", "ou-sandbox", "ou-team-x", "ou-deep")},
+		},
+	}
+	g := newTestGenerator(t, client, Options{RoleName: "my-role", SkipOUs: []string{"ou-sandbox"}})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(accounts) != 1 || accounts[0].Name != "prod_a" {
+		t.Fatalf("got %+v, want only prod_a (everything under ou-sandbox should be skipped)", accounts)
+	}
+	if got := accounts[0].OUPath; len(got) != 3 || got[2].ID != "ou-prod" {
+		t.Errorf("OUPath = %v, want it to end with ou-prod", got)
+	}
+}
+
 func TestGenerator_Accounts_FetchErrorIsNotSilenced(t *testing.T) {
 	wantErr := errors.New("TooManyRequestsException")
 	client := &fakeOrganizationsClient{err: wantErr}
-	g := &generator{client: client, opts: Options{}}
+	g := newTestGenerator(t, client, Options{RoleName: "my-role"})
 
 	_, err := g.Accounts(t.Context())
 	if err == nil {
@@ -75,3 +156,94 @@ func TestGenerator_Accounts_FetchErrorIsNotSilenced(t *testing.T) {
 		t.Errorf("error = %v, want it to wrap %v", err, wantErr)
 	}
 }
+
+// The naming scheme drives Name, which both the credentials profile and the connection name
+// are rendered from.
+func TestGenerator_Accounts_NamingScheme(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "Team Foo", Tags: map[string]string{"steampipe-alias": "foo"}},
+			{ID: "222222222222", Name: "Team Bar"},
+		},
+	}
+	g := newTestGenerator(t, client, Options{RoleName: "my-role", NamingScheme: "tag:steampipe-alias"})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := accountNames(accounts), []string{"foo", "team_bar"}; !equalUnordered(got, want) {
+		t.Errorf("accounts = %v, want %v", got, want)
+	}
+}
+
+// Accounts come back sorted by name with sorted, deduplicated regions, whatever order AWS
+// Organizations listed them in.
+func TestGenerator_Accounts_Sorted(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "333333333333", Name: "charlie"},
+			{ID: "111111111111", Name: "alpha"},
+			{ID: "222222222222", Name: "bravo"},
+		},
+	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:      "my-role",
+		TargetRegions: []string{"us-east-1", "eu-west-1", "us-east-1"},
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, want := accountNames(accounts), []string{"alpha", "bravo", "charlie"}; !slices.Equal(got, want) {
+		t.Errorf("accounts = %v, want %v", got, want)
+	}
+	if got, want := accounts[0].TargetRegions, []string{"eu-west-1", "us-east-1"}; !slices.Equal(got, want) {
+		t.Errorf("TargetRegions = %v, want %v", got, want)
+	}
+	if got := g.opts.TargetRegions; got[0] != "us-east-1" {
+		t.Errorf("Options.TargetRegions was modified in place: %v", got)
+	}
+}
+
+func TestGenerator_Accounts_Partition(t *testing.T) {
+	tests := []struct {
+		name          string
+		arn, region   string
+		configRegion  string
+		wantPartition string
+	}{
+		{name: "aws from ARN", arn: "arn:aws:organizations::999999999999:account/o-example/111111111111", region: "us-gov-west-1", wantPartition: "aws"},
+		{name: "aws-us-gov from ARN", arn: "arn:aws-us-gov:organizations::999999999999:account/o-example/111111111111", wantPartition: "aws-us-gov"},
+		{name: "aws-cn from ARN", arn: "arn:aws-cn:organizations::999999999999:account/o-example/111111111111", wantPartition: "aws-cn"},
+		{name: "aws from region", region: "eu-west-1", wantPartition: "aws"},
+		{name: "aws-us-gov from region", region: "us-gov-west-1", wantPartition: "aws-us-gov"},
+		{name: "aws-cn from region", region: "cn-north-1", wantPartition: "aws-cn"},
+		{name: "aws-cn from config region", region: "eu-west-1", configRegion: "cn-north-1", wantPartition: "aws-cn"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakeOrganizationsClient{
+				accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo", ARN: tt.arn}},
+			}
+			g := newTestGenerator(t, client, Options{RoleName: "my-role", Region: tt.region})
+			g.region = tt.configRegion
+
+			accounts, err := g.Accounts(t.Context())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := accounts[0]
+			if got.Partition != tt.wantPartition {
+				t.Errorf("Partition = %q, want %q", got.Partition, tt.wantPartition)
+			}
+			if want := "arn:" + tt.wantPartition + ":iam::111111111111:role/my-role"; got.RoleARN != want {
+				t.Errorf("RoleARN = %q, want %q", got.RoleARN, want)
+			}
+		})
+	}
+}
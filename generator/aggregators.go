@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// DefaultAggregatorPrefix is the aggregator name prefix used when
+// ConnectionsOptions.AggregatorPrefix is empty: the same "aws_" account connections use.
+const DefaultAggregatorPrefix = connectionNamePrefix
+
+// maxConnectionNameLength is PostgreSQL's identifier limit, which bounds every connection name
+// since Steampipe creates one schema per connection.
+const maxConnectionNameLength = 63
+
+var validAggregatorPrefix = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Aggregator is a Steampipe aggregator connection generated from account tags or
+// organizational units (see ConnectionsOptions).
+type Aggregator struct {
+	// Name is the aggregator's connection name.
+	Name string
+	// Connections lists the connection names of the accounts it aggregates.
+	Connections []string
+
+	// source describes what the aggregator was generated from, e.g. `tag "team" = "foo"`.
+	source string
+}
+
+// buildAggregators generates the aggregators requested by opts, sorted by name. Tag aggregators
+// reuse the aggregateTags grouping and are named prefix + "<key>_<value>"; OU aggregators hold
+// every account in the OU's subtree and are named prefix + "ou_<name>", suffixed with the OU ID
+// for OUs sharing a name. Names are sanitized like account names and must be unique, both
+// among aggregators and against account connection names.
+func buildAggregators(accounts []Account, opts ConnectionsOptions) ([]Aggregator, error) {
+	prefix := cmp.Or(opts.AggregatorPrefix, DefaultAggregatorPrefix)
+	if !validAggregatorPrefix.MatchString(prefix) {
+		return nil, fmt.Errorf("aggregator prefix %q must start with a lowercase letter and contain only lowercase letters, digits and underscores", prefix)
+	}
+
+	var aggregators []Aggregator
+	if len(opts.AggregateByTag) > 0 {
+		for tagKey, names := range aggregateTags(accounts) {
+			key, value, _ := strings.Cut(tagKey, ",")
+			if !slices.Contains(opts.AggregateByTag, key) {
+				continue
+			}
+			aggregators = append(aggregators, Aggregator{
+				Name:        aggregatorName(prefix, key+"_"+value),
+				Connections: connectionNames(names),
+				source:      fmt.Sprintf("tag %q = %q", key, value),
+			})
+		}
+	}
+	if opts.AggregateByOU {
+		aggregators = append(aggregators, ouAggregators(accounts, prefix)...)
+	}
+
+	slices.SortFunc(aggregators, func(a, b Aggregator) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.source, b.source))
+	})
+
+	if err := checkAggregatorNames(accounts, aggregators); err != nil {
+		return nil, err
+	}
+	return aggregators, nil
+}
+
+// ouAggregators returns one aggregator per organizational unit any account sits under, at any
+// depth. The organization root is skipped: its aggregator would hold every account, which the
+// default template's "aws" aggregator already does.
+func ouAggregators(accounts []Account, prefix string) []Aggregator {
+	ous := make(map[string]OrganizationalUnit)
+	members := make(map[string][]string)
+	for _, acc := range accounts {
+		for _, ou := range acc.OUPath {
+			if strings.HasPrefix(ou.ID, "r-") {
+				continue
+			}
+			ous[ou.ID] = ou
+			members[ou.ID] = append(members[ou.ID], acc.Name)
+		}
+	}
+
+	nameCount := make(map[string]int)
+	for _, ou := range ous {
+		nameCount[sanitizeIdentifier(ou.Name)]++
+	}
+
+	aggregators := make([]Aggregator, 0, len(ous))
+	for _, id := range slices.Sorted(maps.Keys(ous)) {
+		name := "ou_" + sanitizeIdentifier(ous[id].Name)
+		if nameCount[sanitizeIdentifier(ous[id].Name)] > 1 {
+			name += "_" + sanitizeIdentifier(id)
+		}
+		aggregators = append(aggregators, Aggregator{
+			Name:        aggregatorName(prefix, name),
+			Connections: connectionNames(members[id]),
+			source:      fmt.Sprintf("organizational unit %s", id),
+		})
+	}
+	return aggregators
+}
+
+// aggregatorName sanitizes name and prefixes it, bounded to maxConnectionNameLength.
+func aggregatorName(prefix, name string) string {
+	return truncateName(prefix+sanitizeIdentifier(name), maxConnectionNameLength)
+}
+
+// connectionNames maps account names to their connection names, sorted.
+func connectionNames(accountNames []string) []string {
+	names := make([]string, 0, len(accountNames))
+	for _, name := range accountNames {
+		names = append(names, connectionNamePrefix+name)
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// checkAggregatorNames rejects an aggregator whose name is shared with another aggregator or
+// an account connection: Steampipe would refuse to load the duplicate connection. Only a clash
+// with an account connection can be fixed with a different aggregator prefix, since every
+// aggregator shares it.
+func checkAggregatorNames(accounts []Account, aggregators []Aggregator) error {
+	accountIDs := make(map[string]string, len(accounts))
+	for _, acc := range accounts {
+		accountIDs[connectionNamePrefix+acc.Name] = acc.ID
+	}
+
+	sources := make(map[string]string, len(aggregators))
+	for _, agg := range aggregators {
+		if id, ok := accountIDs[agg.Name]; ok {
+			return fmt.Errorf("aggregator connection name %q for %s is already used by account %s, choose a different aggregator prefix", agg.Name, agg.source, id)
+		}
+		if other, ok := sources[agg.Name]; ok {
+			return fmt.Errorf("aggregator connection name %q is generated for both %s and %s", agg.Name, other, agg.source)
+		}
+		sources[agg.Name] = agg.source
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func aggregatorFixture() []Account {
+	workloads := OrganizationalUnit{ID: "ou-ab12-workload", Name: "Workloads"}
+	prod := OrganizationalUnit{ID: "ou-ab12-prod0001", Name: "Prod"}
+	teamProd := OrganizationalUnit{ID: "ou-ab12-prod0002", Name: "Prod"}
+	root := OrganizationalUnit{ID: "r-ab12", Name: "Root"}
+
+	return []Account{
+		{Name: "team_foo", Tags: map[string][]string{"team": {"Platform Eng"}, "env": {"prod"}}, OUPath: []OrganizationalUnit{root, workloads, prod}},
+		{Name: "team_bar", Tags: map[string][]string{"team": {"Platform Eng", "data"}, "env": {"dev"}}, OUPath: []OrganizationalUnit{root, workloads}},
+		{Name: "team_baz", Tags: map[string][]string{"cost": {"1"}}, OUPath: []OrganizationalUnit{root, workloads, teamProd}},
+	}
+}
+
+func aggregatorsByName(aggregators []Aggregator) map[string][]string {
+	byName := make(map[string][]string, len(aggregators))
+	for _, agg := range aggregators {
+		byName[agg.Name] = agg.Connections
+	}
+	return byName
+}
+
+func TestBuildAggregators_ByTag(t *testing.T) {
+	aggregators, err := buildAggregators(aggregatorFixture(), ConnectionsOptions{AggregateByTag: []string{"team"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"aws_team_platform_eng": {"aws_team_bar", "aws_team_foo"},
+		"aws_team_data":         {"aws_team_bar"},
+	}
+	got := aggregatorsByName(aggregators)
+	if len(got) != len(want) {
+		t.Fatalf("aggregators = %v, want %v (only the requested tag key)", got, want)
+	}
+	for name, connections := range want {
+		if !slices.Equal(got[name], connections) {
+			t.Errorf("aggregator %q = %v, want %v", name, got[name], connections)
+		}
+	}
+	if !slices.IsSortedFunc(aggregators, func(a, b Aggregator) int { return strings.Compare(a.Name, b.Name) }) {
+		t.Errorf("aggregators aren't sorted by name: %v", aggregators)
+	}
+}
+
+// OU aggregators hold every account in the OU's subtree, skip the root, and disambiguate OUs
+// sharing a name with their ID.
+func TestBuildAggregators_ByOU(t *testing.T) {
+	aggregators, err := buildAggregators(aggregatorFixture(), ConnectionsOptions{AggregateByOU: true, AggregatorPrefix: "agg_"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"agg_ou_workloads":             {"aws_team_bar", "aws_team_baz", "aws_team_foo"},
+		"agg_ou_prod_ou_ab12_prod0001": {"aws_team_foo"},
+		"agg_ou_prod_ou_ab12_prod0002": {"aws_team_baz"},
+	}
+	got := aggregatorsByName(aggregators)
+	if len(got) != len(want) {
+		t.Fatalf("aggregators = %v, want %v", got, want)
+	}
+	for name, connections := range want {
+		if !slices.Equal(got[name], connections) {
+			t.Errorf("aggregator %q = %v, want %v", name, got[name], connections)
+		}
+	}
+}
+
+func TestBuildAggregators_NameClashesWithAccount(t *testing.T) {
+	accounts := []Account{
+		{Name: "team_foo", Tags: map[string][]string{"team": {"foo"}}},
+	}
+	_, err := buildAggregators(accounts, ConnectionsOptions{AggregateByTag: []string{"team"}})
+	if err == nil {
+		t.Fatal("expected an error for an aggregator named like an account connection")
+	}
+	for _, want := range []string{`tag "team" = "foo"`, "aggregator prefix"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+// Two aggregators sharing a name clash whatever the prefix, so the error names both sources and
+// doesn't suggest changing it.
+func TestBuildAggregators_NameClashesWithAggregator(t *testing.T) {
+	accounts := []Account{
+		{Name: "team_foo", Tags: map[string][]string{"team": {"a_b"}}},
+		{Name: "team_bar", Tags: map[string][]string{"team_a": {"b"}}},
+	}
+	_, err := buildAggregators(accounts, ConnectionsOptions{AggregateByTag: []string{"team", "team_a"}})
+	if err == nil {
+		t.Fatal("expected an error for two aggregators sharing a name")
+	}
+	for _, want := range []string{`"aws_team_a_b"`, `tag "team" = "a_b"`, `tag "team_a" = "b"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error = %v, want it to mention %s", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "prefix") {
+		t.Errorf("error = %v, shouldn't suggest changing the aggregator prefix", err)
+	}
+}
+
+func TestBuildAggregators_InvalidPrefix(t *testing.T) {
+	for _, prefix := range []string{"1agg_", "Agg_", "agg-"} {
+		if _, err := buildAggregators(nil, ConnectionsOptions{AggregateByOU: true, AggregatorPrefix: prefix}); err == nil {
+			t.Errorf("expected an error for aggregator prefix %q", prefix)
+		}
+	}
+}
+
+func TestBuildAggregators_NoneRequested(t *testing.T) {
+	aggregators, err := buildAggregators(aggregatorFixture(), ConnectionsOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aggregators) != 0 {
+		t.Errorf("aggregators = %v, want none", aggregators)
+	}
+}
+
+func TestRenderConnections_Aggregators(t *testing.T) {
+	tmpl, err := ParseConnectionsTemplate("")
+	if err != nil {
+		t.Fatalf("unexpected error parsing default template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderConnections(&buf, aggregatorFixture(), tmpl, ConnectionsOptions{AggregateByTag: []string{"env"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `connection "aws_env_prod" {
+  plugin      = "aws"
+  type        = "aggregator"
+  connections = ["aws_team_foo"]
+}`
+	if !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q, got:\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+// DefaultRoleSessionName is the role_session_name of profiles without one set.
+const DefaultRoleSessionName = "steampipe"
+
+// MinDurationSeconds and MaxDurationSeconds bound AssumeRoleOptions.DurationSeconds, as STS
+// does: from 15 minutes up to 12 hours, the longest maximum session duration a role can have.
+const (
+	MinDurationSeconds = 900
+	MaxDurationSeconds = 43200
+)
+
+// The character sets STS accepts for each AssumeRoleOptions field.
+var (
+	externalIDPattern      = regexp.MustCompile(`^[\w+=,.@:/-]+$`)
+	roleSessionNamePattern = regexp.MustCompile(`^[\w+=,.@-]+$`)
+	mfaSerialPattern       = regexp.MustCompile(`^[\w+=/:,.@-]+$`)
+)
+
+// validate checks every field of o that is set.
+func (o AssumeRoleOptions) validate() error {
+	if o.ExternalID != "" && (len(o.ExternalID) < 2 || len(o.ExternalID) > 1224 || !externalIDPattern.MatchString(o.ExternalID)) {
+		return fmt.Errorf("external ID %q must be 2 to 1224 letters, digits or any of +=,.@:/-_", o.ExternalID)
+	}
+	if o.DurationSeconds != 0 && (o.DurationSeconds < MinDurationSeconds || o.DurationSeconds > MaxDurationSeconds) {
+		return fmt.Errorf("duration %d seconds must be between %d and %d", o.DurationSeconds, MinDurationSeconds, MaxDurationSeconds)
+	}
+	if o.RoleSessionName != "" && (len(o.RoleSessionName) < 2 || len(o.RoleSessionName) > 64 || !roleSessionNamePattern.MatchString(o.RoleSessionName)) {
+		return fmt.Errorf("role session name %q must be 2 to 64 letters, digits or any of +=,.@-_", o.RoleSessionName)
+	}
+	if o.MFASerial != "" && (len(o.MFASerial) < 9 || len(o.MFASerial) > 256 || !mfaSerialPattern.MatchString(o.MFASerial)) {
+		return fmt.Errorf("MFA serial %q must be an MFA device ARN or serial number", o.MFASerial)
+	}
+	return nil
+}
+
+// merge returns o with every field set in override replaced.
+func (o AssumeRoleOptions) merge(override AssumeRoleOptions) AssumeRoleOptions {
+	return AssumeRoleOptions{
+		ExternalID:      cmp.Or(override.ExternalID, o.ExternalID),
+		DurationSeconds: cmp.Or(override.DurationSeconds, o.DurationSeconds),
+		RoleSessionName: cmp.Or(override.RoleSessionName, o.RoleSessionName),
+		MFASerial:       cmp.Or(override.MFASerial, o.MFASerial),
+	}
+}
+
+// validateAssumeRole checks Options.AssumeRole and Options.AccountAssumeRole. Tag overrides can
+// only be checked once the accounts are fetched, by accountAssumeRole.
+func validateAssumeRole(opts Options) error {
+	if opts.SSOStartURL != "" && (opts.AssumeRole != AssumeRoleOptions{} || len(opts.AccountAssumeRole) > 0) {
+		return fmt.Errorf("assume-role options don't apply to SSO profiles")
+	}
+	if err := opts.AssumeRole.validate(); err != nil {
+		return err
+	}
+	for id, o := range opts.AccountAssumeRole {
+		if err := o.validate(); err != nil {
+			return fmt.Errorf("account %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// accountAssumeRole returns the assume-role settings of acc: Options.AssumeRole, overridden by
+// Options.AccountAssumeRole, then by acc's override tags.
+func accountAssumeRole(opts Options, acc internalaws.Account) (AssumeRoleOptions, error) {
+	o := opts.AssumeRole.merge(opts.AccountAssumeRole[acc.ID])
+	if opts.OverrideTagPrefix != "" {
+		tagged, err := tagAssumeRole(opts.OverrideTagPrefix, acc.Tags)
+		if err != nil {
+			return AssumeRoleOptions{}, fmt.Errorf("account %s: %w", acc.ID, err)
+		}
+		o = o.merge(tagged)
+	}
+	o.RoleSessionName = cmp.Or(o.RoleSessionName, DefaultRoleSessionName)
+
+	if err := o.validate(); err != nil {
+		return AssumeRoleOptions{}, fmt.Errorf("account %s: %w", acc.ID, err)
+	}
+	return o, nil
+}
+
+// tagAssumeRole returns the assume-role settings set by the tags named prefix followed by an
+// OverrideTag* key.
+func tagAssumeRole(prefix string, tags map[string]string) (AssumeRoleOptions, error) {
+	o := AssumeRoleOptions{
+		ExternalID:      tags[prefix+OverrideTagExternalID],
+		RoleSessionName: tags[prefix+OverrideTagRoleSessionName],
+		MFASerial:       tags[prefix+OverrideTagMFASerial],
+	}
+	if value, ok := tags[prefix+OverrideTagDurationSeconds]; ok {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return AssumeRoleOptions{}, fmt.Errorf("tag %q: %q is not a number of seconds", prefix+OverrideTagDurationSeconds, value)
+		}
+		o.DurationSeconds = seconds
+	}
+	return o, nil
+}
@@ -0,0 +1,155 @@
+package generator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func TestAssumeRoleOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    AssumeRoleOptions
+		wantErr bool
+	}{
+		{name: "empty", opts: AssumeRoleOptions{}},
+		{name: "all set", opts: AssumeRoleOptions{
+			ExternalID:      "tenant-42:prod/eu=1",
+			DurationSeconds: 3600,
+			RoleSessionName: "steampipe@laptop",
+			MFASerial:       "arn:aws:iam::123456789012:mfa/alice",
+		}},
+		{name: "shortest duration", opts: AssumeRoleOptions{DurationSeconds: MinDurationSeconds}},
+		{name: "longest duration", opts: AssumeRoleOptions{DurationSeconds: MaxDurationSeconds}},
+		{name: "duration too short", opts: AssumeRoleOptions{DurationSeconds: MinDurationSeconds - 1}, wantErr: true},
+		{name: "duration too long", opts: AssumeRoleOptions{DurationSeconds: MaxDurationSeconds + 1}, wantErr: true},
+		{name: "negative duration", opts: AssumeRoleOptions{DurationSeconds: -1}, wantErr: true},
+		{name: "external ID with a space", opts: AssumeRoleOptions{ExternalID: "tenant 42"}, wantErr: true},
+		{name: "external ID too short", opts: AssumeRoleOptions{ExternalID: "x"}, wantErr: true},
+		{name: "external ID too long", opts: AssumeRoleOptions{ExternalID: strings.Repeat("x", 1225)}, wantErr: true},
+		{name: "role session name with a colon", opts: AssumeRoleOptions{RoleSessionName: "steam:pipe"}, wantErr: true},
+		{name: "role session name too long", opts: AssumeRoleOptions{RoleSessionName: strings.Repeat("x", 65)}, wantErr: true},
+		{name: "MFA serial with a newline", opts: AssumeRoleOptions{MFASerial: "arn:aws:iam::123456789012:mfa/alice\nx"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidAssumeRole(t *testing.T) {
+	tests := map[string]Options{
+		"duration":            {RoleName: "my-role", AssumeRole: AssumeRoleOptions{DurationSeconds: 60}},
+		"account external ID": {RoleName: "my-role", AccountAssumeRole: map[string]AssumeRoleOptions{"111111111111": {ExternalID: "a b"}}},
+		"SSO profiles": {
+			RoleName:    "ReadOnlyAccess",
+			Region:      "eu-west-1",
+			SSOStartURL: "https://example.awsapps.com/start",
+			AssumeRole:  AssumeRoleOptions{ExternalID: "tenant-42"},
+		},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(t.Context(), opts); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// Each level overrides the one before it: Options.AssumeRole, then Options.AccountAssumeRole,
+// then the account's tags.
+func TestGenerator_Accounts_AssumeRole(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "defaults"},
+			{ID: "222222222222", Name: "account-override"},
+			{ID: "333333333333", Name: "tag-override", Tags: map[string]string{
+				"steampipe:external_id":      "from-tag",
+				"steampipe:duration_seconds": "7200",
+			}},
+		},
+	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:   "my-role",
+		AssumeRole: AssumeRoleOptions{ExternalID: "default-id", MFASerial: "arn:aws:iam::999999999999:mfa/ops"},
+		AccountAssumeRole: map[string]AssumeRoleOptions{
+			"222222222222": {ExternalID: "account-id", RoleSessionName: "audit"},
+			"333333333333": {ExternalID: "account-id", RoleSessionName: "audit"},
+		},
+		OverrideTagPrefix: "steampipe:",
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]AssumeRoleOptions{
+		"111111111111": {ExternalID: "default-id", RoleSessionName: DefaultRoleSessionName, MFASerial: "arn:aws:iam::999999999999:mfa/ops"},
+		"222222222222": {ExternalID: "account-id", RoleSessionName: "audit", MFASerial: "arn:aws:iam::999999999999:mfa/ops"},
+		"333333333333": {ExternalID: "from-tag", DurationSeconds: 7200, RoleSessionName: "audit", MFASerial: "arn:aws:iam::999999999999:mfa/ops"},
+	}
+	for _, acc := range accounts {
+		if acc.AssumeRoleOptions != want[acc.ID] {
+			t.Errorf("account %s: AssumeRoleOptions = %+v, want %+v", acc.ID, acc.AssumeRoleOptions, want[acc.ID])
+		}
+	}
+}
+
+func TestGenerator_Accounts_InvalidAssumeRoleTag(t *testing.T) {
+	for _, tags := range []map[string]string{
+		{"steampipe:duration_seconds": "1h"},
+		{"steampipe:duration_seconds": "60"},
+		{"steampipe:role_session_name": "a session"},
+	} {
+		client := &fakeOrganizationsClient{
+			accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo", Tags: tags}},
+		}
+		g := newTestGenerator(t, client, Options{RoleName: "my-role", OverrideTagPrefix: "steampipe:"})
+
+		_, err := g.Accounts(t.Context())
+		if err == nil || !strings.Contains(err.Error(), "111111111111") {
+			t.Errorf("tags %v: error = %v, want one naming the account", tags, err)
+		}
+	}
+}
+
+func TestRenderCredentials_AssumeRoleOptions(t *testing.T) {
+	accounts := []Account{{
+		Name:             "team_foo",
+		RoleARN:          "arn:aws:iam::111111111111:role/my-role",
+		CredentialSource: "Environment",
+		AssumeRoleOptions: AssumeRoleOptions{
+			ExternalID:      "tenant-42",
+			DurationSeconds: 3600,
+			RoleSessionName: "audit",
+			MFASerial:       "arn:aws:iam::999999999999:mfa/ops",
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := RenderCredentials(&buf, accounts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `[team_foo]
+role_arn = arn:aws:iam::111111111111:role/my-role
+credential_source = Environment
+role_session_name = audit
+external_id = tenant-42
+duration_seconds = 3600
+mfa_serial = arn:aws:iam::999999999999:mfa/ops
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
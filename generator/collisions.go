@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Name collision strategies for Options.NameCollision.
+const (
+	// NameCollisionFail fails the run, listing every collision found. It's the default.
+	NameCollisionFail = "fail"
+	// NameCollisionSuffixID appends "_<account ID>" to every colliding account's name.
+	NameCollisionSuffixID = "suffix-id"
+	// NameCollisionSuffixCounter keeps the name for the colliding account with the lowest
+	// account ID and appends "_2", "_3"... to the others, in account ID order.
+	NameCollisionSuffixCounter = "suffix-counter"
+)
+
+// NameCollisionStrategies lists the valid Options.NameCollision strategies, the default first.
+var NameCollisionStrategies = []string{NameCollisionFail, NameCollisionSuffixID, NameCollisionSuffixCounter}
+
+func validateNameCollision(strategy string) error {
+	if strategy != "" && !slices.Contains(NameCollisionStrategies, strategy) {
+		return fmt.Errorf("unknown name collision strategy %q, valid strategies are: %s", strategy, strings.Join(NameCollisionStrategies, ", "))
+	}
+	return nil
+}
+
+// resolveNameCollisions finds accounts sharing the same Name - distinct AWS account names such
+// as "Team-Foo" and "team foo" normalize to the same "team_foo" - and either fails or renames
+// them according to strategy (empty means NameCollisionFail). Every account involved in a
+// resolved collision, renamed or not, gets its shared name recorded in CollidedName.
+func resolveNameCollisions(accounts []Account, strategy string) error {
+	if err := validateNameCollision(strategy); err != nil {
+		return err
+	}
+
+	byName := make(map[string][]*Account)
+	for i := range accounts {
+		byName[accounts[i].Name] = append(byName[accounts[i].Name], &accounts[i])
+	}
+
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(byName)) {
+		colliding := byName[name]
+		if len(colliding) < 2 {
+			continue
+		}
+		slices.SortFunc(colliding, func(a, b *Account) int { return cmp.Compare(a.ID, b.ID) })
+
+		switch strategy {
+		case NameCollisionSuffixID:
+			for _, acc := range colliding {
+				acc.Name = appendNameSuffix(name, acc.ID)
+				acc.CollidedName = name
+			}
+		case NameCollisionSuffixCounter:
+			colliding[0].CollidedName = name
+			n := 2
+			for _, acc := range colliding[1:] {
+				for byName[appendNameSuffix(name, strconv.Itoa(n))] != nil {
+					n++
+				}
+				acc.Name = appendNameSuffix(name, strconv.Itoa(n))
+				acc.CollidedName = name
+				n++
+			}
+		default:
+			ids := make([]string, 0, len(colliding))
+			for _, acc := range colliding {
+				ids = append(ids, fmt.Sprintf("%s (%q)", acc.ID, acc.OriginalName))
+			}
+			errs = append(errs, fmt.Errorf("account name %q is shared by accounts %s", name, strings.Join(ids, ", ")))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("account name collisions, choose a name collision strategy to resolve them: %w", errors.Join(errs...))
+	}
+
+	return checkUniqueNames(accounts)
+}
+
+// checkUniqueNames guards against a renamed account landing on another account's name, e.g.
+// an account literally named "team_foo_2" next to two "team_foo" accounts resolved by counter
+// - the counter skips names already taken, but a suffixed ID could still clash.
+func checkUniqueNames(accounts []Account) error {
+	seen := make(map[string]string, len(accounts))
+	for _, acc := range accounts {
+		if other, ok := seen[acc.Name]; ok {
+			return fmt.Errorf("account name %q is still shared by accounts %s and %s after resolving collisions", acc.Name, other, acc.ID)
+		}
+		seen[acc.Name] = acc.ID
+	}
+	return nil
+}
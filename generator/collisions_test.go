@@ -0,0 +1,145 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func collidingAccounts() []Account {
+	return []Account{
+		{ID: "333333333333", Name: "team_foo"},
+		{ID: "111111111111", Name: "team_foo"},
+		{ID: "444444444444", Name: "team_bar"},
+		{ID: "222222222222", Name: "team_foo"},
+	}
+}
+
+func namesByID(accounts []Account) map[string]string {
+	names := make(map[string]string, len(accounts))
+	for _, acc := range accounts {
+		names[acc.ID] = acc.Name
+	}
+	return names
+}
+
+func TestResolveNameCollisions_Fail(t *testing.T) {
+	for _, strategy := range []string{"", NameCollisionFail} {
+		err := resolveNameCollisions(collidingAccounts(), strategy)
+		if err == nil {
+			t.Fatalf("strategy %q: expected an error for colliding names", strategy)
+		}
+		for _, want := range []string{`"team_foo"`, "111111111111", "222222222222", "333333333333"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("strategy %q: error should mention %s, got: %v", strategy, want, err)
+			}
+		}
+		if strings.Contains(err.Error(), "444444444444") {
+			t.Errorf("strategy %q: error should not mention the non-colliding account, got: %v", strategy, err)
+		}
+	}
+}
+
+func TestResolveNameCollisions_SuffixID(t *testing.T) {
+	accounts := collidingAccounts()
+	if err := resolveNameCollisions(accounts, NameCollisionSuffixID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"111111111111": "team_foo_111111111111",
+		"222222222222": "team_foo_222222222222",
+		"333333333333": "team_foo_333333333333",
+		"444444444444": "team_bar",
+	}
+	for id, name := range namesByID(accounts) {
+		if name != want[id] {
+			t.Errorf("account %s: Name = %q, want %q", id, name, want[id])
+		}
+	}
+	for _, acc := range accounts {
+		wantCollided := ""
+		if acc.ID != "444444444444" {
+			wantCollided = "team_foo"
+		}
+		if acc.CollidedName != wantCollided {
+			t.Errorf("account %s: CollidedName = %q, want %q", acc.ID, acc.CollidedName, wantCollided)
+		}
+	}
+}
+
+// The counter is assigned in account ID order, not API order, so names are stable between
+// runs; it also skips a suffixed name that's already taken by another account.
+func TestResolveNameCollisions_SuffixCounter(t *testing.T) {
+	accounts := append(collidingAccounts(), Account{ID: "555555555555", Name: "team_foo_2"})
+	if err := resolveNameCollisions(accounts, NameCollisionSuffixCounter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"111111111111": "team_foo",
+		"222222222222": "team_foo_3",
+		"333333333333": "team_foo_4",
+		"444444444444": "team_bar",
+		"555555555555": "team_foo_2",
+	}
+	for id, name := range namesByID(accounts) {
+		if name != want[id] {
+			t.Errorf("account %s: Name = %q, want %q", id, name, want[id])
+		}
+	}
+}
+
+func TestResolveNameCollisions_SuffixCollidesWithExistingName(t *testing.T) {
+	accounts := []Account{
+		{ID: "111111111111", Name: "team_foo"},
+		{ID: "222222222222", Name: "team_foo"},
+		{ID: "333333333333", Name: "team_foo_111111111111"},
+	}
+	if err := resolveNameCollisions(accounts, NameCollisionSuffixID); err == nil {
+		t.Fatal("expected an error when a suffixed name collides with another account's name")
+	}
+}
+
+func TestResolveNameCollisions_UnknownStrategy(t *testing.T) {
+	if err := resolveNameCollisions(nil, "bogus"); err == nil {
+		t.Fatal("expected an error for an unknown strategy")
+	}
+}
+
+func TestNew_InvalidNameCollision(t *testing.T) {
+	_, err := New(t.Context(), Options{RoleName: "my-role", NameCollision: "bogus"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown name collision strategy")
+	}
+}
+
+func TestGenerator_Accounts_NameCollision(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "Team-Foo"},
+			{ID: "222222222222", Name: "team foo"},
+		},
+	}
+
+	g := newTestGenerator(t, client, Options{RoleName: "my-role"})
+	_, err := g.Accounts(t.Context())
+	if err == nil {
+		t.Fatal("expected an error for colliding names with the default strategy")
+	}
+	for _, want := range []string{`"Team-Foo"`, `"team foo"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention the original name %s, got: %v", want, err)
+		}
+	}
+
+	g.opts.NameCollision = NameCollisionSuffixID
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := accountNames(accounts), []string{"team_foo_111111111111", "team_foo_222222222222"}; !equalUnordered(got, want) {
+		t.Errorf("accounts = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// validateCredentials checks that each profile gets exactly one way to get its credentials:
+// Options.CredentialSource or Options.SourceProfile to assume its role with, or
+// Options.SSOStartURL to sign in through IAM Identity Center.
+func validateCredentials(opts Options) error {
+	if opts.CredentialSource != "" && opts.SourceProfile != "" {
+		return fmt.Errorf("credential source %q and source profile %q are mutually exclusive", opts.CredentialSource, opts.SourceProfile)
+	}
+	if opts.SSOStartURL != "" && (opts.CredentialSource != "" || opts.SourceProfile != "") {
+		return fmt.Errorf("SSO profiles can't have a credential source or source profile")
+	}
+	if opts.SourceProfile != "" {
+		if err := validateProfileName("source profile", opts.SourceProfile); err != nil {
+			return err
+		}
+	}
+	return validateSSO(opts)
+}
+
+// validateProfileName rejects a name that can't be written in an AWS config file section or
+// setting as is.
+func validateProfileName(what, name string) error {
+	if strings.ContainsAny(name, " \t\r\n[]") {
+		return fmt.Errorf("%s %q must not contain whitespace or brackets", what, name)
+	}
+	return nil
+}
+
+// checkSourceProfile rejects a source profile that is also one of the generated profiles: the
+// profile would then assume its own role with itself, which the AWS SDKs reject as a loop.
+func checkSourceProfile(accounts []Account, sourceProfile string) error {
+	if sourceProfile == "" {
+		return nil
+	}
+	for _, account := range accounts {
+		if account.Name == sourceProfile {
+			return fmt.Errorf("source profile %q is also the generated profile of account %s, pick another name", sourceProfile, account.ID)
+		}
+	}
+	return nil
+}
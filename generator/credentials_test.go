@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func TestValidateCredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "credential source", opts: Options{CredentialSource: "Environment"}},
+		{name: "source profile", opts: Options{SourceProfile: "sso-hub"}},
+		{name: "neither", opts: Options{}},
+		{name: "both", opts: Options{CredentialSource: "Environment", SourceProfile: "sso-hub"}, wantErr: true},
+		{name: "source profile with whitespace", opts: Options{SourceProfile: "sso hub"}, wantErr: true},
+		{name: "source profile with a newline", opts: Options{SourceProfile: "sso-hub\ncredential_source = Environment"}, wantErr: true},
+		{name: "source profile with brackets", opts: Options{SourceProfile: "[sso-hub]"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCredentials(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew_CredentialSourceAndSourceProfile(t *testing.T) {
+	_, err := New(t.Context(), Options{RoleName: "my-role", CredentialSource: "Environment", SourceProfile: "sso-hub"})
+	if err == nil {
+		t.Fatal("expected an error for both a credential source and a source profile")
+	}
+}
+
+func TestGenerator_Accounts_SourceProfile(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo"}},
+	}
+
+	g := newTestGenerator(t, client, Options{RoleName: "my-role", SourceProfile: "sso-hub"})
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := accounts[0]; got.SourceProfile != "sso-hub" || got.CredentialSource != "" {
+		t.Errorf("SourceProfile, CredentialSource = %q, %q, want %q, none", got.SourceProfile, got.CredentialSource, "sso-hub")
+	}
+
+	// A generated profile named like the source profile would source its own credentials.
+	g.opts.SourceProfile = "team_foo"
+	if _, err := g.Accounts(t.Context()); err == nil {
+		t.Fatal("expected an error for a source profile that is also a generated profile")
+	}
+}
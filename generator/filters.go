@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+// accountPatternRegexpPrefix marks an Options.IncludeAccounts/ExcludeAccounts entry as a
+// regular expression rather than a glob.
+const accountPatternRegexpPrefix = "re:"
+
+// accountFilters holds the Options filters that are compiled once per run: the tag filter
+// expression and the account name/ID patterns.
+type accountFilters struct {
+	tags    tagFilter
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// compileFilters compiles opts' filters, so New can reject an invalid one before any AWS call.
+func compileFilters(opts Options) (accountFilters, error) {
+	tags, err := parseTagFilter(opts.TagFilter)
+	if err != nil {
+		return accountFilters{}, err
+	}
+	include, err := compileAccountPatterns(opts.IncludeAccounts)
+	if err != nil {
+		return accountFilters{}, fmt.Errorf("include accounts: %w", err)
+	}
+	exclude, err := compileAccountPatterns(opts.ExcludeAccounts)
+	if err != nil {
+		return accountFilters{}, fmt.Errorf("exclude accounts: %w", err)
+	}
+	return accountFilters{tags: tags, include: include, exclude: exclude}, nil
+}
+
+// compileAccountPatterns compiles each pattern into an anchored regular expression: a pattern
+// prefixed with "re:" is a regular expression, used as-is (so it's only anchored if written
+// that way), and anything else, including a plain account ID, is a glob (see compileGlob).
+func compileAccountPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		expr, ok := strings.CutPrefix(pattern, accountPatternRegexpPrefix)
+		if !ok {
+			compiled = append(compiled, compileGlob(pattern))
+			continue
+		}
+
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// matchAccount reports whether an account passes the name/ID patterns: it must match one of
+// the include patterns (if any are set) and none of the exclude patterns, checking its ID,
+// raw Organizations name and normalized name.
+func (f accountFilters) matchAccount(acc internalaws.Account, normalizedName string) bool {
+	candidates := []string{acc.ID, acc.Name, normalizedName}
+	if len(f.include) > 0 && !matchesAnyPattern(f.include, candidates) {
+		return false
+	}
+	return !matchesAnyPattern(f.exclude, candidates)
+}
+
+func matchesAnyPattern(patterns []*regexp.Regexp, candidates []string) bool {
+	for _, re := range patterns {
+		for _, s := range candidates {
+			if re.MatchString(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
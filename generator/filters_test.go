@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func accountPatternFixture() *fakeOrganizationsClient {
+	return &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "Break-Glass"},
+			{ID: "222222222222", Name: "Log Archive"},
+			{ID: "333333333333", Name: "Team Foo"},
+			{ID: "444444444444", Name: "Team Bar"},
+		},
+	}
+}
+
+func TestGenerator_Accounts_AccountPatterns(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{
+			name:    "exclude by account ID",
+			exclude: []string{"111111111111"},
+			want:    []string{"log_archive", "team_foo", "team_bar"},
+		},
+		{
+			name:    "exclude by raw name",
+			exclude: []string{"Log Archive"},
+			want:    []string{"break_glass", "team_foo", "team_bar"},
+		},
+		{
+			name:    "exclude by normalized name glob",
+			exclude: []string{"break_*", "log_*"},
+			want:    []string{"team_foo", "team_bar"},
+		},
+		{
+			name:    "include by raw name glob",
+			include: []string{"Team *"},
+			want:    []string{"team_foo", "team_bar"},
+		},
+		{
+			name:    "include by regular expression",
+			include: []string{"re:^(?i)team.(foo|baz)$"},
+			want:    []string{"team_foo"},
+		},
+		{
+			name:    "include by ID glob",
+			include: []string{"4444*"},
+			want:    []string{"team_bar"},
+		},
+		{
+			name:    "exclude wins over include",
+			include: []string{"team_*"},
+			exclude: []string{"re:bar"},
+			want:    []string{"team_foo"},
+		},
+		{
+			name:    "glob without wildcards must match whole name",
+			exclude: []string{"Team"},
+			want:    []string{"break_glass", "log_archive", "team_foo", "team_bar"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGenerator(t, accountPatternFixture(), Options{
+				RoleName:        "my-role",
+				IncludeAccounts: tt.include,
+				ExcludeAccounts: tt.exclude,
+			})
+
+			accounts, err := g.Accounts(t.Context())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := accountNames(accounts); !equalUnordered(got, tt.want) {
+				t.Errorf("accounts = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidAccountPattern(t *testing.T) {
+	_, err := New(t.Context(), Options{RoleName: "my-role", ExcludeAccounts: []string{"re:team_(foo"}})
+	if err == nil {
+		t.Fatal("expected an error for an invalid regular expression")
+	}
+}
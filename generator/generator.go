@@ -3,6 +3,7 @@
 package generator
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 
@@ -11,8 +12,14 @@ import (
 
 // Generator fetches AWS Organizations accounts for Steampipe config generation.
 type Generator interface {
-	// Accounts fetches active accounts, excluding any organizational unit listed in
-	// Options.SkipOUs, with each account's tags attached.
+	// Accounts fetches active accounts, restricted to those under an organizational unit
+	// listed in Options.IncludeOUs (if any) and excluding those under one listed in
+	// Options.SkipOUs, at any depth, and to those matching Options.TagFilter and
+	// Options.IncludeAccounts/ExcludeAccounts, and to those provisioned one of
+	// Options.PermissionSets if set, with each account's tags attached, and its enabled regions
+	// if Options.RegionDiscovery is set. Each account is named per Options.NamingScheme, and
+	// accounts whose names collide are handled according to Options.NameCollision. Accounts
+	// are sorted by Name, so the same organization state always yields the same result.
 	Accounts(ctx context.Context) ([]Account, error)
 }
 
@@ -20,19 +27,49 @@ type Generator interface {
 // rather than in internal/aws where it's implemented - internal/aws.NewOrganizationsClient
 // returns a real, SDK-backed implementation; tests use an in-memory fake instead.
 type OrganizationsClient interface {
-	// ListAccounts returns all ACTIVE accounts in the organization, with tags and OU populated.
-	ListAccounts(ctx context.Context) ([]internalaws.Account, error)
+	// ListAccounts returns all ACTIVE accounts in the organization, with tags, OU and OUPath
+	// populated, and the organization's root(s) and every organizational unit nested below
+	// them, from the same walk of the organization tree.
+	ListAccounts(ctx context.Context) ([]internalaws.Account, []internalaws.OrganizationalUnit, error)
+}
+
+// SSOAdminClient lists the IAM Identity Center permission sets provisioned to accounts. Like
+// OrganizationsClient, internal/aws.NewSSOAdminClient implements it, and tests fake it.
+type SSOAdminClient interface {
+	// ListPermissionSets returns the names of the permission sets provisioned to each of
+	// accountIDs, by account ID.
+	ListPermissionSets(ctx context.Context, accountIDs []string) (map[string][]string, error)
+}
+
+// AccountClient lists the regions enabled in accounts. Like OrganizationsClient,
+// internal/aws.NewAccountClient implements it, and tests fake it.
+type AccountClient interface {
+	// ListEnabledRegions returns the regions enabled in each of targets, by account ID.
+	ListEnabledRegions(ctx context.Context, targets []internalaws.RegionsTarget) (map[string][]string, error)
 }
 
 type generator struct {
 	client OrganizationsClient
-	opts   Options
+	// ssoAdmin is only set if Options.PermissionSets is.
+	ssoAdmin SSOAdminClient
+	// account is only set if Options.RegionDiscovery is.
+	account AccountClient
+	opts    Options
+	// region is the loaded AWS config's region, if any. Like Options.Region, it tells the
+	// partition of an account whose ARN doesn't.
+	region string
+
+	// filters, roles and namer are compiled from opts once, by newGenerator.
+	filters accountFilters
+	roles   roleResolver
+	namer   accountNamer
 }
 
 // New returns a Generator configured from the default AWS environment, assuming
 // opts.AssumeRoleArn first if set.
 func New(ctx context.Context, opts Options) (Generator, error) {
-	if err := validateTagSplit(opts.TagSplit); err != nil {
+	g, err := newGenerator(opts)
+	if err != nil {
 		return nil, err
 	}
 
@@ -44,8 +81,48 @@ func New(ctx context.Context, opts Options) (Generator, error) {
 		return nil, fmt.Errorf("loading aws config: %w", err)
 	}
 
-	return &generator{
-		client: internalaws.NewOrganizationsClient(cfg),
-		opts:   opts,
-	}, nil
+	g.client = internalaws.NewOrganizationsClient(cfg)
+	g.region = cfg.Region
+	if len(opts.PermissionSets) > 0 {
+		g.ssoAdmin = internalaws.NewSSOAdminClient(cfg, cmp.Or(opts.SSORegion, opts.Region))
+	}
+	if opts.RegionDiscovery != "" {
+		g.account = internalaws.NewAccountClient(cfg)
+	}
+	return g, nil
+}
+
+// newGenerator checks opts and compiles its filters, roles and naming scheme, so New can reject
+// invalid options before any AWS call, and Accounts doesn't compile them again. It's left to
+// the caller to set the clients.
+func newGenerator(opts Options) (*generator, error) {
+	if err := validateTagSplit(opts.TagSplit); err != nil {
+		return nil, err
+	}
+	filters, err := compileFilters(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateNameCollision(opts.NameCollision); err != nil {
+		return nil, err
+	}
+	if err := validateCredentials(opts); err != nil {
+		return nil, err
+	}
+	if err := validateAssumeRole(opts); err != nil {
+		return nil, err
+	}
+	roles, err := compileRoles(opts)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRegionDiscovery(opts); err != nil {
+		return nil, err
+	}
+	namer, err := parseNamingScheme(opts.NamingScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	return &generator{opts: opts, filters: filters, roles: roles, namer: namer}, nil
 }
@@ -0,0 +1,29 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+)
+
+// compileGlob compiles a shell-style glob, where "*" matches any run of characters (including
+// none) and "?" matches exactly one, into an anchored regular expression. Every other
+// character matches itself: unlike path.Match, "/" isn't special and there are no character
+// classes, since both "/" and "[" are ordinary characters in AWS names and tag values.
+func compileGlob(pattern string) *regexp.Regexp {
+	var expr strings.Builder
+	expr.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+
+	// Every metacharacter is quoted above, so the result is always a valid expression.
+	return regexp.MustCompile(expr.String())
+}
@@ -0,0 +1,29 @@
+package generator
+
+import "testing"
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		want       bool
+	}{
+		{"prod", "prod", true},
+		{"prod", "production", false},
+		{"prod*", "production", true},
+		{"*", "", true},
+		{"p?od", "prod", true},
+		{"p?od", "pod", false},
+		// "/", "[" and "." are literal characters, not path separators or metacharacters.
+		{"team/*", "team/a/b", true},
+		{"[prod]", "[prod]", true},
+		{"[prod]", "p", false},
+		{"a.b", "axb", false},
+		{"line*", "line\nbreak", true},
+	}
+
+	for _, tt := range tests {
+		if got := compileGlob(tt.pattern).MatchString(tt.s); got != tt.want {
+			t.Errorf("compileGlob(%q).MatchString(%q) = %v, want %v", tt.pattern, tt.s, got, tt.want)
+		}
+	}
+}
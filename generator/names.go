@@ -0,0 +1,137 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Naming schemes for Options.NamingScheme, besides NamingSchemeTagPrefix and templates.
+const (
+	// NamingSchemeName uses the normalized account name. It's the default.
+	NamingSchemeName = "name"
+	// NamingSchemeID uses the bare account ID: "111111111111", so the connection is
+	// "aws_111111111111". It's the only scheme whose Name may start with a digit; the "aws_"
+	// connection name prefix still makes it a valid identifier.
+	NamingSchemeID = "id"
+	// NamingSchemeNameID uses the normalized account name suffixed with the account ID:
+	// "team_foo_111111111111".
+	NamingSchemeNameID = "name-id"
+	// NamingSchemeTagPrefix, followed by a tag key (e.g. "tag:steampipe-alias"), uses that
+	// tag's value, falling back to the normalized account name for accounts without it.
+	NamingSchemeTagPrefix = "tag:"
+)
+
+// accountNamer returns the Name for an account built by Accounts, whose Name is still the
+// normalized AWS account name. Its result is always normalized again, or is NamingSchemeID's
+// bare account ID, so every scheme yields a valid connection identifier.
+type accountNamer func(acc Account) (string, error)
+
+// parseNamingScheme returns the accountNamer for scheme (empty means NamingSchemeName). A
+// scheme containing "{{" is a text/template executed with the Account, e.g.
+// `{{ index .Tags "env" 0 }}_{{ .Name }}`.
+func parseNamingScheme(scheme string) (accountNamer, error) {
+	switch {
+	case scheme == "" || scheme == NamingSchemeName:
+		return func(acc Account) (string, error) { return acc.Name, nil }, nil
+	case scheme == NamingSchemeID:
+		return func(acc Account) (string, error) { return acc.ID, nil }, nil
+	case scheme == NamingSchemeNameID:
+		return func(acc Account) (string, error) { return appendNameSuffix(acc.Name, acc.ID), nil }, nil
+	case strings.HasPrefix(scheme, NamingSchemeTagPrefix):
+		key := strings.TrimPrefix(scheme, NamingSchemeTagPrefix)
+		if key == "" {
+			return nil, fmt.Errorf("naming scheme %q is missing a tag key", scheme)
+		}
+		return func(acc Account) (string, error) {
+			values := acc.Tags[key]
+			if len(values) == 0 || strings.Join(values, "") == "" {
+				return acc.Name, nil
+			}
+			return normalizeAccountName(strings.Join(values, "_")), nil
+		}, nil
+	case strings.Contains(scheme, "{{"):
+		tmpl, err := template.New("naming").Option("missingkey=error").Parse(scheme)
+		if err != nil {
+			return nil, fmt.Errorf("parsing naming scheme template: %w", err)
+		}
+		return func(acc Account) (string, error) {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, acc); err != nil {
+				return "", fmt.Errorf("account %s: executing naming scheme template: %w", acc.ID, err)
+			}
+			if strings.TrimSpace(buf.String()) == "" {
+				return "", fmt.Errorf("account %s: naming scheme template produced an empty name", acc.ID)
+			}
+			return normalizeAccountName(buf.String()), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown naming scheme %q, valid schemes are: %s, %s, %s, %s<key>, or a Go template", scheme, NamingSchemeName, NamingSchemeID, NamingSchemeNameID, NamingSchemeTagPrefix)
+	}
+}
+
+// connectionNamePrefix is prepended to an account's Name to build its Steampipe connection
+// name, both by the default connections template and by generated aggregators.
+const connectionNamePrefix = "aws_"
+
+// maxAccountNameLength bounds an account's Name so its connection name stays within
+// PostgreSQL's 63-byte identifier limit: Steampipe creates one schema per connection, and a
+// longer name would be silently truncated, and could then collide with another connection.
+const maxAccountNameLength = 63 - len(connectionNamePrefix)
+
+// fallbackAccountName is used for a name with no character left after normalization, and
+// prefixed to one starting with a digit.
+const fallbackAccountName = "account"
+
+// normalizeAccountName maps an AWS account name to a valid Steampipe connection identifier:
+// lowercase ASCII letters, digits and underscores only, starting with a letter, with no
+// repeated, leading or trailing underscores, and at most maxAccountNameLength bytes long.
+// Every other character, including non-ASCII letters, becomes an underscore.
+func normalizeAccountName(name string) string {
+	normalized := sanitizeIdentifier(name)
+	switch {
+	case normalized == "":
+		return fallbackAccountName
+	case normalized[0] >= '0' && normalized[0] <= '9':
+		normalized = fallbackAccountName + "_" + normalized
+	}
+	return truncateName(normalized, maxAccountNameLength)
+}
+
+// sanitizeIdentifier lowercases s and replaces every character other than ASCII letters,
+// digits and underscores with an underscore, then collapses and trims underscores. Unlike
+// normalizeAccountName, the result may be empty, start with a digit or be of any length.
+func sanitizeIdentifier(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	return collapseUnderscores(b.String())
+}
+
+// appendNameSuffix appends "_" + suffix to name, truncating name first if needed so the
+// result still fits within maxAccountNameLength - the suffix, which is what makes the result
+// unique, is always kept whole.
+func appendNameSuffix(name, suffix string) string {
+	return truncateName(name, maxAccountNameLength-len(suffix)-1) + "_" + suffix
+}
+
+// collapseUnderscores replaces every run of underscores with a single one and trims them from
+// both ends.
+func collapseUnderscores(s string) string {
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return r == '_' }), "_")
+}
+
+// truncateName cuts an already-normalized (so single-byte-per-character) name to at most max
+// bytes, without leaving a trailing underscore.
+func truncateName(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+	return strings.TrimRight(name[:max], "_")
+}
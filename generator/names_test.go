@@ -0,0 +1,151 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// validConnectionName is the identifier set Steampipe accepts for a connection name.
+var validConnectionName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func TestNormalizeAccountName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		// Legacy behavior: lowercase, spaces and hyphens become underscores.
+		{"Team Foo", "team_foo"},
+		{"team-bar", "team_bar"},
+		{"Team_Foo", "team_foo"},
+		{"Sandbox-Dev-01", "sandbox_dev_01"},
+		// Real-world organization account names.
+		{"Log Archive", "log_archive"},
+		{"Audit", "audit"},
+		{"prod.payments", "prod_payments"},
+		{"R&D", "r_d"},
+		{"Data (EU)", "data_eu"},
+		{"c++ tooling", "c_tooling"},
+		{"team/platform", "team_platform"},
+		{"Shared Services - Network", "shared_services_network"},
+		{"  padded name  ", "padded_name"},
+		{"__private__", "private"},
+		{"acme@example.com", "acme_example_com"},
+		{"Équipe Données", "quipe_donn_es"},
+		{"123 Corp", "account_123_corp"},
+		{"2024-sandbox", "account_2024_sandbox"},
+		// Nothing usable left.
+		{"", "account"},
+		{"&&&", "account"},
+		{"日本", "account"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizeAccountName(tt.name)
+			if got != tt.want {
+				t.Errorf("normalizeAccountName(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+			if !validConnectionName.MatchString(connectionNamePrefix + got) {
+				t.Errorf("normalizeAccountName(%q) = %q is not a valid connection identifier", tt.name, got)
+			}
+		})
+	}
+}
+
+func TestNormalizeAccountName_LengthBounded(t *testing.T) {
+	long := strings.Repeat("very long account name ", 10)
+
+	got := normalizeAccountName(long)
+	if len(got) > maxAccountNameLength {
+		t.Errorf("len(%q) = %d, want <= %d", got, len(got), maxAccountNameLength)
+	}
+	if strings.HasSuffix(got, "_") {
+		t.Errorf("%q has a trailing underscore after truncation", got)
+	}
+	if !validConnectionName.MatchString(got) {
+		t.Errorf("%q is not a valid connection identifier", got)
+	}
+}
+
+// A collision suffix must survive truncation whole, since it's what makes the name unique.
+func TestAppendNameSuffix(t *testing.T) {
+	if got, want := appendNameSuffix("team_foo", "2"), "team_foo_2"; got != want {
+		t.Errorf("appendNameSuffix() = %q, want %q", got, want)
+	}
+
+	long := normalizeAccountName(strings.Repeat("a", 100))
+	got := appendNameSuffix(long, "111111111111")
+	if len(got) > maxAccountNameLength {
+		t.Errorf("len(%q) = %d, want <= %d", got, len(got), maxAccountNameLength)
+	}
+	if !strings.HasSuffix(got, "_111111111111") {
+		t.Errorf("%q lost its suffix", got)
+	}
+}
+
+func TestParseNamingScheme(t *testing.T) {
+	acc := Account{
+		ID:   "111111111111",
+		Name: "team_foo",
+		Tags: map[string][]string{"steampipe-alias": {"Payments Prod"}, "env": {"prod"}},
+	}
+	untagged := Account{ID: "222222222222", Name: "team_bar"}
+
+	tests := []struct {
+		scheme       string
+		want         string
+		wantUntagged string
+	}{
+		{"", "team_foo", "team_bar"},
+		{NamingSchemeName, "team_foo", "team_bar"},
+		{NamingSchemeID, "111111111111", "222222222222"},
+		{NamingSchemeNameID, "team_foo_111111111111", "team_bar_222222222222"},
+		{"tag:steampipe-alias", "payments_prod", "team_bar"},
+		{`{{ .Name }}-{{ .ID }}`, "team_foo_111111111111", "team_bar_222222222222"},
+		{`{{ with index .Tags "env" }}{{ index . 0 }}_{{ end }}{{ .Name }}`, "prod_team_foo", "team_bar"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scheme, func(t *testing.T) {
+			namer, err := parseNamingScheme(tt.scheme)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got, err := namer(acc); err != nil || got != tt.want {
+				t.Errorf("namer(tagged) = %q, %v, want %q", got, err, tt.want)
+			}
+			if got, err := namer(untagged); err != nil || got != tt.wantUntagged {
+				t.Errorf("namer(untagged) = %q, %v, want %q", got, err, tt.wantUntagged)
+			}
+		})
+	}
+}
+
+func TestParseNamingScheme_Invalid(t *testing.T) {
+	for _, scheme := range []string{"bogus", "tag:", "{{ .Name "} {
+		if _, err := parseNamingScheme(scheme); err == nil {
+			t.Errorf("expected an error for naming scheme %q", scheme)
+		}
+	}
+}
+
+func TestParseNamingScheme_TemplateErrors(t *testing.T) {
+	for _, scheme := range []string{`{{ .NoSuchField }}`, `{{ "" }}`} {
+		namer, err := parseNamingScheme(scheme)
+		if err != nil {
+			t.Fatalf("%q: unexpected parse error: %v", scheme, err)
+		}
+		if _, err := namer(Account{ID: "111111111111", Name: "team_foo"}); err == nil {
+			t.Errorf("%q: expected an execution error", scheme)
+		}
+	}
+}
+
+func TestNew_InvalidNamingScheme(t *testing.T) {
+	_, err := New(t.Context(), Options{RoleName: "my-role", NamingScheme: "bogus"})
+	if err == nil {
+		t.Fatal("expected an error for an unknown naming scheme")
+	}
+}
@@ -0,0 +1,63 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+// validateOUs checks that every OU ID listed in Options.IncludeOUs and Options.SkipOUs, or
+// matched by one of Options.RoleRules, is one of units, the organization's, so a typo fails
+// loudly instead of silently selecting (or keeping) the wrong accounts.
+func (g *generator) validateOUs(units []internalaws.OrganizationalUnit, roleOUs []string) error {
+	exists := make(map[string]bool, len(units))
+	for _, ou := range units {
+		exists[ou.ID] = true
+	}
+
+	var errs []error
+	for _, id := range g.opts.IncludeOUs {
+		if !exists[id] {
+			errs = append(errs, fmt.Errorf("included organizational unit %q doesn't exist in the organization", id))
+		}
+	}
+	for _, id := range g.opts.SkipOUs {
+		if !exists[id] {
+			errs = append(errs, fmt.Errorf("skipped organizational unit %q doesn't exist in the organization", id))
+		}
+	}
+	for _, id := range roleOUs {
+		if !exists[id] {
+			errs = append(errs, fmt.Errorf("role rule organizational unit %q doesn't exist in the organization", id))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// inSelectedOUs reports whether an account with the given OU path passes the OU filters: it
+// must be under one of Options.IncludeOUs (if any are set) and under none of Options.SkipOUs.
+// Both match whole subtrees, and a skip always wins over an include.
+func (g *generator) inSelectedOUs(path []internalaws.OrganizationalUnit) bool {
+	if len(g.opts.IncludeOUs) > 0 && !underAnyOU(path, g.opts.IncludeOUs) {
+		return false
+	}
+	return !underAnyOU(path, g.opts.SkipOUs)
+}
+
+// underAnyOU reports whether an account with the given OU path sits anywhere in the subtree of
+// one of ouIDs, i.e. whether any of its ancestors (not just its direct parent) is listed.
+func underAnyOU(path []internalaws.OrganizationalUnit, ouIDs []string) bool {
+	return slices.ContainsFunc(path, func(ou internalaws.OrganizationalUnit) bool {
+		return slices.Contains(ouIDs, ou.ID)
+	})
+}
+
+func convertOUPath(path []internalaws.OrganizationalUnit) []OrganizationalUnit {
+	converted := make([]OrganizationalUnit, 0, len(path))
+	for _, ou := range path {
+		converted = append(converted, OrganizationalUnit{ID: ou.ID, Name: ou.Name})
+	}
+	return converted
+}
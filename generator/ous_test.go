@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func ouFixture() *fakeOrganizationsClient {
+	return &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "prod-a", OUPath: ouPath("r-root", "ou-workloads", "ou-prod")},
+			{ID: "222222222222", Name: "prod-b", OUPath: ouPath("r-root", "ou-workloads", "ou-prod", "ou-eu")},
+			{ID: "333333333333", Name: "dev-a", OUPath: ouPath("r-root", "ou-workloads", "ou-dev")},
+			{ID: "444444444444", Name: "security", OUPath: ouPath("r-root", "ou-security")},
+		},
+	}
+}
+
+func accountNames(accounts []Account) []string {
+	names := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		names = append(names, acc.Name)
+	}
+	return names
+}
+
+// IncludeOUs selects an OU's whole subtree, not just the accounts directly inside it.
+func TestGenerator_Accounts_IncludeOUs(t *testing.T) {
+	g := newTestGenerator(t, ouFixture(), Options{RoleName: "my-role", IncludeOUs: []string{"ou-prod"}})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := accountNames(accounts), []string{"prod_a", "prod_b"}; !equalUnordered(got, want) {
+		t.Errorf("accounts = %v, want %v", got, want)
+	}
+}
+
+func TestGenerator_Accounts_IncludeAndSkipOUs_SkipWins(t *testing.T) {
+	g := newTestGenerator(t, ouFixture(), Options{
+		RoleName:   "my-role",
+		IncludeOUs: []string{"ou-workloads"},
+		SkipOUs:    []string{"ou-eu", "ou-dev"},
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := accountNames(accounts), []string{"prod_a"}; !equalUnordered(got, want) {
+		t.Errorf("accounts = %v, want %v", got, want)
+	}
+}
+
+func TestGenerator_Accounts_UnknownOU(t *testing.T) {
+	g := newTestGenerator(t, ouFixture(), Options{
+		RoleName:   "my-role",
+		IncludeOUs: []string{"ou-prod", "ou-typo"},
+		SkipOUs:    []string{"ou-gone"},
+	})
+
+	_, err := g.Accounts(t.Context())
+	if err == nil {
+		t.Fatal("expected an error for OU IDs that don't exist in the organization")
+	}
+	for _, want := range []string{"ou-typo", "ou-gone"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error should mention %q, got: %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), `"ou-prod"`) {
+		t.Errorf("error should not mention the existing ou-prod, got: %v", err)
+	}
+}
+
+// The OU filters are checked against the same walk of the organization tree that lists the
+// accounts, and an OU without accounts is as valid as any other.
+func TestGenerator_Accounts_OUsValidatedFromSingleWalk(t *testing.T) {
+	client := ouFixture()
+	client.units = ouPath("ou-empty")
+	g := newTestGenerator(t, client, Options{RoleName: "my-role", IncludeOUs: []string{"ou-prod"}, SkipOUs: []string{"ou-empty"}})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := accountNames(accounts), []string{"prod_a", "prod_b"}; !equalUnordered(got, want) {
+		t.Errorf("accounts = %v, want %v", got, want)
+	}
+	if client.listCalls != 1 {
+		t.Errorf("ListAccounts called %d times, want 1", client.listCalls)
+	}
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+// Tag keys overriding an account's settings, following Options.OverrideTagPrefix.
+const (
+	OverrideTagExternalID      = "external_id"
+	OverrideTagDurationSeconds = "duration_seconds"
+	OverrideTagRoleSessionName = "role_session_name"
+	OverrideTagMFASerial       = "mfa_serial"
+	// OverrideTagRegions sets the account's TargetRegions: space-separated regions and patterns
+	// as for Options.TargetRegions, "!"-prefixed exclusions included (see
+	// internalaws.ParseRegions), or "all" for every region.
+	OverrideTagRegions = "regions"
+	// OverrideTagImportSchema sets the account's ImportSchema: "enabled" or "disabled".
+	OverrideTagImportSchema = "import_schema"
+	// OverrideTagDefaultRegion sets the account's DefaultRegion.
+	OverrideTagDefaultRegion = "default_region"
+	// OverrideTagSkip leaves the account out if "true".
+	OverrideTagSkip = "skip"
+)
+
+// connectionOverrideTags and assumeRoleOverrideTags list the OverrideTag* keys of connection
+// settings, which apply to every account, and of assume-role ones, which don't apply to SSO
+// profiles.
+var (
+	connectionOverrideTags = []string{
+		OverrideTagSkip,
+		OverrideTagRegions,
+		OverrideTagDefaultRegion,
+		OverrideTagImportSchema,
+	}
+	assumeRoleOverrideTags = []string{
+		OverrideTagExternalID,
+		OverrideTagDurationSeconds,
+		OverrideTagRoleSessionName,
+		OverrideTagMFASerial,
+	}
+)
+
+// validImportSchemas are the values of an import_schema setting.
+var validImportSchemas = []string{"enabled", "disabled"}
+
+// connectionOverrides are the connection settings an account's tags override, each left empty
+// if not overridden, and the regions they set that aren't known to this build.
+type connectionOverrides struct {
+	skip           bool
+	targetRegions  []string
+	defaultRegion  string
+	importSchema   string
+	unknownRegions []string
+}
+
+// tagConnectionOverrides returns the connection settings set by the tags named prefix followed
+// by an OverrideTag* key, for an account in partition.
+func tagConnectionOverrides(prefix, partition string, tags map[string]string) (connectionOverrides, error) {
+	var o connectionOverrides
+	if value, ok := tags[prefix+OverrideTagSkip]; ok {
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return connectionOverrides{}, fmt.Errorf("tag %q: %q must be true or false", prefix+OverrideTagSkip, value)
+		}
+		o.skip = skip
+	}
+	if value, ok := tags[prefix+OverrideTagRegions]; ok {
+		regions, unknown, err := internalaws.ParseRegions(partition, strings.Fields(value))
+		if err != nil {
+			return connectionOverrides{}, fmt.Errorf("tag %q: %w", prefix+OverrideTagRegions, err)
+		}
+		o.targetRegions = sortedRegions(regions)
+		o.unknownRegions = unknown
+	}
+	if value, ok := tags[prefix+OverrideTagDefaultRegion]; ok {
+		err := internalaws.ValidateRegion(partition, value)
+		if errors.Is(err, internalaws.ErrUnknownRegion) {
+			if !slices.Contains(o.unknownRegions, value) {
+				o.unknownRegions = append(o.unknownRegions, value)
+			}
+		} else if err != nil {
+			return connectionOverrides{}, fmt.Errorf("tag %q: %w", prefix+OverrideTagDefaultRegion, err)
+		}
+		o.defaultRegion = value
+	}
+	if value, ok := tags[prefix+OverrideTagImportSchema]; ok {
+		if !slices.Contains(validImportSchemas, value) {
+			return connectionOverrides{}, fmt.Errorf("tag %q: %q must be one of %s", prefix+OverrideTagImportSchema, value, strings.Join(validImportSchemas, ", "))
+		}
+		o.importSchema = value
+	}
+	return o, nil
+}
+
+// appliedOverrides returns the values of the tags named prefix followed by one of keys, by
+// key, or nil if there's none.
+func appliedOverrides(prefix string, tags map[string]string, keys ...[]string) map[string]string {
+	if prefix == "" {
+		return nil
+	}
+
+	var applied map[string]string
+	for _, key := range slices.Concat(keys...) {
+		if value, ok := tags[prefix+key]; ok {
+			if applied == nil {
+				applied = make(map[string]string)
+			}
+			applied[key] = value
+		}
+	}
+	return applied
+}
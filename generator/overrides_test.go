@@ -0,0 +1,164 @@
+package generator
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func TestTagConnectionOverrides(t *testing.T) {
+	tests := []struct {
+		name    string
+		tags    map[string]string
+		want    connectionOverrides
+		wantErr bool
+	}{
+		{name: "none", tags: map[string]string{"team": "foo"}},
+		{name: "skip", tags: map[string]string{"sp:skip": "true"}, want: connectionOverrides{skip: true}},
+		{name: "no skip", tags: map[string]string{"sp:skip": "false"}},
+		{name: "regions", tags: map[string]string{"sp:regions": " us-east-1  eu-west-1 us-east-1"}, want: connectionOverrides{targetRegions: []string{"eu-west-1", "us-east-1"}}},
+		{name: "all regions", tags: map[string]string{"sp:regions": "all"}, want: connectionOverrides{targetRegions: []string{"*"}}},
+		{name: "region patterns", tags: map[string]string{"sp:regions": "us-east-1 eu-*"}, want: connectionOverrides{targetRegions: []string{"eu-*", "us-east-1"}}},
+		{name: "region exclusions", tags: map[string]string{"sp:regions": "eu-west-* !eu-west-3"}, want: connectionOverrides{targetRegions: []string{"eu-west-1", "eu-west-2"}}},
+		{name: "excluding every region", tags: map[string]string{"sp:regions": "eu-west-1 !eu-*"}, wantErr: true},
+		{name: "default region", tags: map[string]string{"sp:default_region": "ap-east-1"}, want: connectionOverrides{defaultRegion: "ap-east-1"}},
+		{name: "import schema", tags: map[string]string{"sp:import_schema": "disabled"}, want: connectionOverrides{importSchema: "disabled"}},
+		{name: "invalid skip", tags: map[string]string{"sp:skip": "yes"}, wantErr: true},
+		{name: "empty regions", tags: map[string]string{"sp:regions": " "}, wantErr: true},
+		{name: "invalid region", tags: map[string]string{"sp:regions": "eu-west-1 europe"}, wantErr: true},
+		{name: "all among regions", tags: map[string]string{"sp:regions": "all eu-west-1"}, wantErr: true},
+		{name: "invalid default region", tags: map[string]string{"sp:default_region": "eu_west_1"}, wantErr: true},
+		{name: "unknown region", tags: map[string]string{"sp:regions": "eu-wset-1"}, wantErr: true},
+		{name: "region in another partition", tags: map[string]string{"sp:default_region": "us-gov-west-1"}, wantErr: true},
+		{name: "unknown well-formed regions", tags: map[string]string{"sp:regions": "eu-west-4 eu-west-1", "sp:default_region": "eu-west-4"}, want: connectionOverrides{targetRegions: []string{"eu-west-1", "eu-west-4"}, defaultRegion: "eu-west-4", unknownRegions: []string{"eu-west-4"}}},
+		{name: "invalid import schema", tags: map[string]string{"sp:import_schema": "off"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tagConnectionOverrides("sp:", internalaws.PartitionAWS, tt.tags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("tagConnectionOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.skip != tt.want.skip || !slices.Equal(got.targetRegions, tt.want.targetRegions) ||
+				got.defaultRegion != tt.want.defaultRegion || got.importSchema != tt.want.importSchema ||
+				!slices.Equal(got.unknownRegions, tt.want.unknownRegions) {
+				t.Errorf("tagConnectionOverrides() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_Accounts_Overrides(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "defaults"},
+			{ID: "222222222222", Name: "overridden", Tags: map[string]string{
+				"steampipe:regions":        "eu-west-1 eu-central-1 eu-central-9",
+				"steampipe:default_region": "eu-west-1",
+				"steampipe:import_schema":  "disabled",
+				"steampipe:external_id":    "tenant-42",
+			}},
+			{ID: "333333333333", Name: "skipped", Tags: map[string]string{"steampipe:skip": "true"}},
+		},
+	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:          "my-role",
+		Region:            "us-east-1",
+		ImportSchema:      "enabled",
+		TargetRegions:     []string{"*"},
+		OverrideTagPrefix: "steampipe:",
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := accountNames(accounts), []string{"defaults", "overridden"}; !slices.Equal(got, want) {
+		t.Fatalf("accounts = %v, want %v", got, want)
+	}
+
+	defaults, overridden := accounts[0], accounts[1]
+	if defaults.ImportSchema != "enabled" || defaults.DefaultRegion != "us-east-1" || !slices.Equal(defaults.TargetRegions, []string{"*"}) || defaults.Overrides != nil {
+		t.Errorf("defaults = %q, %q, %v, %v, want the options' settings and no overrides", defaults.ImportSchema, defaults.DefaultRegion, defaults.TargetRegions, defaults.Overrides)
+	}
+	if overridden.ImportSchema != "disabled" || overridden.DefaultRegion != "eu-west-1" || !slices.Equal(overridden.TargetRegions, []string{"eu-central-1", "eu-central-9", "eu-west-1"}) {
+		t.Errorf("overridden = %q, %q, %v, want the tags' settings", overridden.ImportSchema, overridden.DefaultRegion, overridden.TargetRegions)
+	}
+	if defaults.UnknownRegions != nil || !slices.Equal(overridden.UnknownRegions, []string{"eu-central-9"}) {
+		t.Errorf("UnknownRegions = %v, %v, want none and eu-central-9", defaults.UnknownRegions, overridden.UnknownRegions)
+	}
+	wantOverrides := map[string]string{
+		OverrideTagRegions:       "eu-west-1 eu-central-1 eu-central-9",
+		OverrideTagDefaultRegion: "eu-west-1",
+		OverrideTagImportSchema:  "disabled",
+		OverrideTagExternalID:    "tenant-42",
+	}
+	if !maps.Equal(overridden.Overrides, wantOverrides) {
+		t.Errorf("Overrides = %v, want %v", overridden.Overrides, wantOverrides)
+	}
+}
+
+// Without an override tag prefix, no tag overrides anything, even one named after a setting.
+func TestGenerator_Accounts_OverridesDisabled(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo", Tags: map[string]string{
+			"skip":              "true",
+			"steampipe:regions": "eu-west-1",
+		}}},
+	}
+	g := newTestGenerator(t, client, Options{RoleName: "my-role", TargetRegions: []string{"*"}})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(accounts) != 1 || !slices.Equal(accounts[0].TargetRegions, []string{"*"}) || accounts[0].Overrides != nil {
+		t.Errorf("accounts = %+v, want team_foo with no overrides", accounts)
+	}
+}
+
+// SSO profiles only report the overrides that apply to them.
+func TestGenerator_Accounts_OverridesSSO(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo", Tags: map[string]string{
+			"steampipe:import_schema": "disabled",
+			"steampipe:external_id":   "tenant-42",
+		}}},
+	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:          "ReadOnlyAccess",
+		Region:            "eu-west-1",
+		SSOStartURL:       "https://example.awsapps.com/start",
+		OverrideTagPrefix: "steampipe:",
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]string{OverrideTagImportSchema: "disabled"}; !maps.Equal(accounts[0].Overrides, want) {
+		t.Errorf("Overrides = %v, want %v", accounts[0].Overrides, want)
+	}
+}
+
+func TestGenerator_Accounts_InvalidOverrideTag(t *testing.T) {
+	for _, tags := range []map[string]string{
+		{"steampipe:skip": "maybe"},
+		{"steampipe:regions": "eu-wset"},
+		{"steampipe:import_schema": "off"},
+	} {
+		client := &fakeOrganizationsClient{
+			accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo", Tags: tags}},
+		}
+		g := newTestGenerator(t, client, Options{RoleName: "my-role", OverrideTagPrefix: "steampipe:"})
+
+		_, err := g.Accounts(t.Context())
+		if err == nil || !strings.Contains(err.Error(), "111111111111") {
+			t.Errorf("tags %v: error = %v, want one naming the account", tags, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+// Options.RegionDiscovery modes: how to list the regions enabled in each account.
+const (
+	// RegionDiscoveryOrganization lists them with the organization credentials, which must be
+	// the management account's or an Account Management delegated administrator's.
+	RegionDiscoveryOrganization = "organization"
+	// RegionDiscoveryMemberRole lists them from inside each account, assuming its RoleARN with
+	// the organization credentials.
+	RegionDiscoveryMemberRole = "member-role"
+)
+
+// validateRegionDiscovery checks Options.RegionDiscovery.
+func validateRegionDiscovery(opts Options) error {
+	switch opts.RegionDiscovery {
+	case "", RegionDiscoveryOrganization:
+		return nil
+	case RegionDiscoveryMemberRole:
+		if opts.SSOStartURL != "" {
+			return fmt.Errorf("region discovery %q needs a role to assume, which SSO profiles don't have", opts.RegionDiscovery)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown region discovery %q; valid values are %q and %q", opts.RegionDiscovery, RegionDiscoveryOrganization, RegionDiscoveryMemberRole)
+	}
+}
+
+// discoverRegions sets each account's TargetRegions to the regions enabled in it that match
+// one of its TargetRegions so far, globs such as "*" or "eu-*". An account with no such region
+// is an error: its connection would query nothing.
+func (g *generator) discoverRegions(ctx context.Context, accounts []Account) error {
+	if g.account == nil {
+		return fmt.Errorf("region discovery needs an Account Management client")
+	}
+
+	targets := make([]internalaws.RegionsTarget, 0, len(accounts))
+	for _, acc := range accounts {
+		target := internalaws.RegionsTarget{AccountID: acc.ID}
+		if g.opts.RegionDiscovery == RegionDiscoveryMemberRole {
+			if acc.MFASerial != "" {
+				return fmt.Errorf("account %s: region discovery can't assume a role needing MFA", acc.ID)
+			}
+			target.RoleARN = acc.RoleARN
+			target.ExternalID = acc.ExternalID
+		}
+		targets = append(targets, target)
+	}
+
+	enabled, err := g.account.ListEnabledRegions(ctx, targets)
+	if err != nil {
+		return fmt.Errorf("discovering enabled regions: %w", err)
+	}
+
+	for i := range accounts {
+		patterns := make([]*regexp.Regexp, 0, len(accounts[i].TargetRegions))
+		for _, region := range accounts[i].TargetRegions {
+			patterns = append(patterns, compileGlob(region))
+		}
+		var regions []string
+		for _, region := range enabled[accounts[i].ID] {
+			if slices.ContainsFunc(patterns, func(re *regexp.Regexp) bool { return re.MatchString(region) }) {
+				regions = append(regions, region)
+			}
+		}
+		if len(regions) == 0 {
+			return fmt.Errorf("account %s: none of its enabled regions (%s) matches its regions %s", accounts[i].ID, strings.Join(enabled[accounts[i].ID], ", "), strings.Join(accounts[i].TargetRegions, ", "))
+		}
+		accounts[i].TargetRegions = sortedRegions(regions)
+	}
+	return nil
+}
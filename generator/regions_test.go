@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+// fakeAccountClient is an in-memory AccountClient, returning the enabled regions by account ID
+// and recording the targets it's asked about.
+type fakeAccountClient struct {
+	enabled map[string][]string
+	err     error
+	targets []internalaws.RegionsTarget
+}
+
+func (f *fakeAccountClient) ListEnabledRegions(ctx context.Context, targets []internalaws.RegionsTarget) (map[string][]string, error) {
+	f.targets = targets
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.enabled, nil
+}
+
+func TestValidateRegionDiscovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "disabled", opts: Options{}},
+		{name: "organization", opts: Options{RegionDiscovery: RegionDiscoveryOrganization}},
+		{name: "member role", opts: Options{RegionDiscovery: RegionDiscoveryMemberRole}},
+		{name: "unknown", opts: Options{RegionDiscovery: "bogus"}, wantErr: true},
+		{name: "member role with SSO", opts: Options{RegionDiscovery: RegionDiscoveryMemberRole, SSOStartURL: "https://example.awsapps.com/start"}, wantErr: true},
+		{name: "organization with SSO", opts: Options{RegionDiscovery: RegionDiscoveryOrganization, SSOStartURL: "https://example.awsapps.com/start"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRegionDiscovery(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateRegionDiscovery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Each account gets its own enabled regions, intersected with Options.TargetRegions globs.
+func TestGenerator_Accounts_RegionDiscovery(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "opted-in"},
+			{ID: "222222222222", Name: "defaults"},
+		},
+	}
+	account := &fakeAccountClient{enabled: map[string][]string{
+		"111111111111": {"us-east-1", "eu-west-1", "eu-south-2", "ap-east-1"},
+		"222222222222": {"us-east-1", "eu-west-1"},
+	}}
+	g := newTestGenerator(t, client, Options{
+		RoleName:        "my-role",
+		TargetRegions:   []string{"eu-*", "ap-east-1", "us-west-2"},
+		RegionDiscovery: RegionDiscoveryOrganization,
+	})
+	g.account = account
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"111111111111": {"ap-east-1", "eu-south-2", "eu-west-1"},
+		"222222222222": {"eu-west-1"},
+	}
+	for _, acc := range accounts {
+		if !slices.Equal(acc.TargetRegions, want[acc.ID]) {
+			t.Errorf("account %s: TargetRegions = %v, want %v", acc.ID, acc.TargetRegions, want[acc.ID])
+		}
+	}
+	for _, target := range account.targets {
+		if target.RoleARN != "" {
+			t.Errorf("account %s: RoleARN = %q, want none with the organization credentials", target.AccountID, target.RoleARN)
+		}
+	}
+}
+
+func TestGenerator_Accounts_RegionDiscovery_MemberRole(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo"}},
+	}
+	account := &fakeAccountClient{enabled: map[string][]string{"111111111111": {"us-east-1"}}}
+	g := newTestGenerator(t, client, Options{
+		RoleName:        "my-role",
+		TargetRegions:   []string{"*"},
+		RegionDiscovery: RegionDiscoveryMemberRole,
+		AssumeRole:      AssumeRoleOptions{ExternalID: "tenant-42"},
+	})
+	g.account = account
+
+	if _, err := g.Accounts(t.Context()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []internalaws.RegionsTarget{{AccountID: "111111111111", RoleARN: "arn:aws:iam::111111111111:role/my-role", ExternalID: "tenant-42"}}
+	if !slices.Equal(account.targets, want) {
+		t.Errorf("targets = %+v, want %+v", account.targets, want)
+	}
+}
+
+func TestGenerator_Accounts_RegionDiscovery_Errors(t *testing.T) {
+	boom := errors.New("boom")
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo"}},
+	}
+
+	g := newTestGenerator(t, client, Options{
+		RoleName:        "my-role",
+		TargetRegions:   []string{"*"},
+		RegionDiscovery: RegionDiscoveryOrganization,
+	})
+	g.account = &fakeAccountClient{err: boom}
+	if _, err := g.Accounts(t.Context()); !errors.Is(err, boom) {
+		t.Errorf("error = %v, want it to wrap %v", err, boom)
+	}
+
+	g = newTestGenerator(t, client, Options{
+		RoleName:        "my-role",
+		TargetRegions:   []string{"*"},
+		RegionDiscovery: RegionDiscoveryMemberRole,
+		AssumeRole:      AssumeRoleOptions{MFASerial: "arn:aws:iam::999999999999:mfa/ops"},
+	})
+	g.account = &fakeAccountClient{}
+	if _, err := g.Accounts(t.Context()); err == nil {
+		t.Error("expected an error assuming a role needing MFA")
+	}
+}
+
+// An account with no enabled region matching its regions fails the run, rather than get a
+// connection querying nothing.
+func TestGenerator_Accounts_RegionDiscovery_NoMatch(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "team-foo"},
+			{ID: "222222222222", Name: "team-bar"},
+		},
+	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:        "my-role",
+		TargetRegions:   []string{"eu-*", "ap-east-1"},
+		RegionDiscovery: RegionDiscoveryOrganization,
+	})
+	g.account = &fakeAccountClient{enabled: map[string][]string{
+		"111111111111": {"us-east-1", "eu-west-1"},
+		"222222222222": {"us-east-1", "us-west-2"},
+	}}
+
+	_, err := g.Accounts(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "222222222222") || !strings.Contains(err.Error(), "ap-east-1, eu-*") {
+		t.Errorf("error = %v, want one naming account 222222222222 and its regions", err)
+	}
+}
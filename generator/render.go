@@ -4,6 +4,7 @@ import (
 	"embed"
 	"fmt"
 	"io"
+	"slices"
 	"text/template"
 )
 
@@ -13,10 +14,12 @@ var templatesFS embed.FS
 const defaultConnectionsTemplate = "templates/aws_connections.tmpl"
 
 // connectionsTemplateData is the data passed to the connections template: the accounts
-// themselves, plus a view of their tags aggregated into per-tag-value connection groups.
+// themselves, a view of their tags aggregated into per-tag-value connection groups, and the
+// aggregators requested by ConnectionsOptions.
 type connectionsTemplateData struct {
-	Accounts []Account
-	Tags     map[string][]string
+	Accounts    []Account
+	Tags        map[string][]string
+	Aggregators []Aggregator
 }
 
 // ParseConnectionsTemplate returns the connections template to render with: the embedded
@@ -28,11 +31,22 @@ func ParseConnectionsTemplate(path string) (*template.Template, error) {
 	return template.ParseFiles(path)
 }
 
-// RenderConnections renders the Steampipe AWS connections file for accounts using tmpl.
-func RenderConnections(w io.Writer, accounts []Account, tmpl *template.Template) error {
+// RenderConnections renders the Steampipe AWS connections file for accounts using tmpl,
+// generating aggregators as configured by opts. Accounts are rendered sorted by Name,
+// whatever order they're passed in.
+func RenderConnections(w io.Writer, accounts []Account, tmpl *template.Template, opts ConnectionsOptions) error {
+	accounts = slices.Clone(accounts)
+	sortAccounts(accounts)
+
+	aggregators, err := buildAggregators(accounts, opts)
+	if err != nil {
+		return fmt.Errorf("building aggregators: %w", err)
+	}
+
 	data := connectionsTemplateData{
-		Accounts: accounts,
-		Tags:     aggregateTags(accounts),
+		Accounts:    accounts,
+		Tags:        aggregateTags(accounts),
+		Aggregators: aggregators,
 	}
 
 	if err := tmpl.Execute(w, data); err != nil {
@@ -41,9 +55,20 @@ func RenderConnections(w io.Writer, accounts []Account, tmpl *template.Template)
 	return nil
 }
 
-// RenderCredentials renders the AWS credentials file for accounts.
+// RenderCredentials renders the AWS credentials file for accounts, sorted by Name. SSO
+// profiles can't be rendered there: the AWS SDKs only read sso-session blocks from the config
+// file (see RenderConfig).
 func RenderCredentials(w io.Writer, accounts []Account) error {
-	tmpl, err := template.ParseFS(templatesFS, "templates/aws_credentials.tmpl")
+	accounts = slices.Clone(accounts)
+	sortAccounts(accounts)
+
+	for _, acc := range accounts {
+		if acc.SSOStartURL != "" {
+			return fmt.Errorf("account %s: SSO profiles can only be rendered to the AWS config file", acc.ID)
+		}
+	}
+
+	tmpl, err := parseProfilesTemplate("templates/aws_credentials.tmpl")
 	if err != nil {
 		return fmt.Errorf("parsing credentials template: %w", err)
 	}
@@ -54,9 +79,46 @@ func RenderCredentials(w io.Writer, accounts []Account) error {
 	return nil
 }
 
+// configTemplateData is the data passed to the config template.
+type configTemplateData struct {
+	Accounts    []Account
+	SSOSessions []SSOSession
+	ConfigOptions
+}
+
+// RenderConfig renders the AWS config file for accounts, sorted by Name: the same profiles
+// RenderCredentials renders, in "[profile name]" sections, configured by opts, preceded by the
+// sso-session blocks of any SSO profiles.
+func RenderConfig(w io.Writer, accounts []Account, opts ConfigOptions) error {
+	accounts = slices.Clone(accounts)
+	sortAccounts(accounts)
+
+	sessions, err := ssoSessions(accounts)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := parseProfilesTemplate("templates/aws_config.tmpl")
+	if err != nil {
+		return fmt.Errorf("parsing config template: %w", err)
+	}
+
+	if err := tmpl.Execute(w, configTemplateData{Accounts: accounts, SSOSessions: sessions, ConfigOptions: opts}); err != nil {
+		return fmt.Errorf("rendering config template: %w", err)
+	}
+	return nil
+}
+
+// parseProfilesTemplate parses the embedded template at path along with the "profile"
+// template it shares with the other profile files.
+func parseProfilesTemplate(path string) (*template.Template, error) {
+	return template.ParseFS(templatesFS, path, "templates/aws_profile.tmpl")
+}
+
 // aggregateTags groups account names by "tagKey,tagValue", mirroring the historical template
 // lookup convention (index .Tags "key,value"). A tag with multiple values (see Options.TagSplit)
-// contributes one entry per value.
+// contributes one entry per value. Each group's names are sorted and deduplicated, so the
+// rendered output doesn't depend on the order accounts were fetched in.
 func aggregateTags(accounts []Account) map[string][]string {
 	tagged := make(map[string][]string)
 	for _, acc := range accounts {
@@ -67,5 +129,9 @@ func aggregateTags(accounts []Account) map[string][]string {
 			}
 		}
 	}
+	for tagKey, names := range tagged {
+		slices.Sort(names)
+		tagged[tagKey] = slices.Compact(names)
+	}
 	return tagged
 }
@@ -2,8 +2,10 @@ package generator
 
 import (
 	"bytes"
+	"slices"
 	"strings"
 	"testing"
+	"text/template"
 )
 
 func TestRenderCredentials(t *testing.T) {
@@ -68,7 +70,7 @@ func TestRenderConnections_DefaultTemplate(t *testing.T) {
 	}
 
 	var buf bytes.Buffer
-	if err := RenderConnections(&buf, accounts, tmpl); err != nil {
+	if err := RenderConnections(&buf, accounts, tmpl, ConnectionsOptions{}); err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
@@ -80,6 +82,183 @@ func TestRenderConnections_DefaultTemplate(t *testing.T) {
 	}
 }
 
+// Custom templates can use every Account field, not just the ones the default template does.
+func TestRenderConnections_AccountMetadata(t *testing.T) {
+	accounts := []Account{{
+		ID:           "111111111111",
+		Name:         "team_foo",
+		OriginalName: "Team Foo",
+		Email:        "foo@example.com",
+		JoinedMethod: "CREATED",
+		OU:           OrganizationalUnit{ID: "ou-prod", Name: "Prod"},
+	}}
+
+	tmpl, err := template.New("custom").Parse(`{{ range .Accounts }}# {{ .OriginalName }} ({{ .ID }}, {{ .Email }}, {{ .JoinedMethod }}) in {{ .OU.Name }}{{ end }}`)
+	if err != nil {
+		t.Fatalf("unexpected error parsing template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := RenderConnections(&buf, accounts, tmpl, ConnectionsOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "# Team Foo (111111111111, foo@example.com, CREATED) in Prod"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+// The same organization state must render byte-identical files, whatever order accounts and
+// their tag groups were built in, so git-tracked configs only change when the org does.
+func TestRenderConnections_Deterministic(t *testing.T) {
+	tmpl, err := ParseConnectionsTemplate("")
+	if err != nil {
+		t.Fatalf("unexpected error parsing default template: %v", err)
+	}
+	opts := ConnectionsOptions{AggregateByTag: []string{"team"}}
+
+	render := func(accounts []Account) string {
+		var buf bytes.Buffer
+		if err := RenderConnections(&buf, accounts, tmpl, opts); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return buf.String()
+	}
+
+	accounts := []Account{
+		{Name: "team_a", TargetRegions: []string{"*"}, Tags: map[string][]string{"team": {"x"}}},
+		{Name: "team_b", TargetRegions: []string{"*"}, Tags: map[string][]string{"team": {"x", "y"}}},
+		{Name: "team_c", TargetRegions: []string{"*"}, Tags: map[string][]string{"team": {"y"}}},
+	}
+	reversed := slices.Clone(accounts)
+	slices.Reverse(reversed)
+
+	if first, second := render(accounts), render(reversed); first != second {
+		t.Errorf("output differs by input order:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestRenderCredentials_SortedByName(t *testing.T) {
+	accounts := []Account{
+		{Name: "team_b", RoleARN: "arn:aws:iam::222222222222:role/my-role", CredentialSource: "Environment"},
+		{Name: "team_a", RoleARN: "arn:aws:iam::111111111111:role/my-role", CredentialSource: "Environment"},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderCredentials(&buf, accounts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	if strings.Index(out, "[team_a]") > strings.Index(out, "[team_b]") {
+		t.Errorf("profiles aren't sorted by name:\n%s", out)
+	}
+	if accounts[0].Name != "team_b" {
+		t.Error("RenderCredentials reordered the caller's slice")
+	}
+}
+
+// The default credentials output is relied on byte-for-byte by existing users' diffs and by
+// --mergeCredentials, so pin it exactly.
+func TestRenderCredentials_Exact(t *testing.T) {
+	accounts := []Account{
+		{Name: "team_foo", RoleARN: "arn:aws:iam::111111111111:role/my-role", CredentialSource: "Environment", DefaultRegion: "eu-west-1"},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderCredentials(&buf, accounts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `[team_foo]
+role_arn = arn:aws:iam::111111111111:role/my-role
+credential_source = Environment
+role_session_name = steampipe
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderCredentials_SourceProfile(t *testing.T) {
+	accounts := []Account{
+		{Name: "team_foo", RoleARN: "arn:aws:iam::111111111111:role/my-role", SourceProfile: "sso-hub"},
+	}
+
+	var buf bytes.Buffer
+	if err := RenderCredentials(&buf, accounts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `[team_foo]
+role_arn = arn:aws:iam::111111111111:role/my-role
+source_profile = sso-hub
+role_session_name = steampipe
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderConfig(t *testing.T) {
+	accounts := []Account{
+		{Name: "team_foo", RoleARN: "arn:aws:iam::111111111111:role/my-role", CredentialSource: "Environment", DefaultRegion: "eu-west-1"},
+		{Name: "team_bar", RoleARN: "arn:aws:iam::222222222222:role/my-role", CredentialSource: "Environment", DefaultRegion: "eu-west-1"},
+	}
+
+	tests := []struct {
+		name string
+		opts ConfigOptions
+		want string
+	}{
+		{
+			name: "without region",
+			want: `[profile team_bar]
+role_arn = arn:aws:iam::222222222222:role/my-role
+credential_source = Environment
+role_session_name = steampipe
+
+[profile team_foo]
+role_arn = arn:aws:iam::111111111111:role/my-role
+credential_source = Environment
+role_session_name = steampipe
+
+`,
+		},
+		{
+			name: "with region",
+			opts: ConfigOptions{IncludeRegion: true},
+			want: `[profile team_bar]
+role_arn = arn:aws:iam::222222222222:role/my-role
+credential_source = Environment
+role_session_name = steampipe
+region = eu-west-1
+
+[profile team_foo]
+role_arn = arn:aws:iam::111111111111:role/my-role
+credential_source = Environment
+role_session_name = steampipe
+region = eu-west-1
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderConfig(&buf, accounts, tt.opts); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestParseConnectionsTemplate_InvalidPath(t *testing.T) {
 	_, err := ParseConnectionsTemplate("/no/such/template.tmpl")
 	if err == nil {
@@ -96,16 +275,10 @@ func TestAggregateTags(t *testing.T) {
 
 	got := aggregateTags(accounts)
 
-	want := []string{"team_foo", "team_bar"}
-	names := got["sandbox_account,true"]
-	if len(names) != len(want) {
-		t.Fatalf("aggregateTags()[sandbox_account,true] = %v, want %v", names, want)
-	}
-	seen := map[string]bool{names[0]: true, names[1]: true}
-	for _, w := range want {
-		if !seen[w] {
-			t.Errorf("aggregateTags()[sandbox_account,true] missing %q, got %v", w, names)
-		}
+	// Sorted, not in account order.
+	want := []string{"team_bar", "team_foo"}
+	if names := got["sandbox_account,true"]; !slices.Equal(names, want) {
+		t.Errorf("aggregateTags()[sandbox_account,true] = %v, want %v", names, want)
 	}
 
 	if names := got["sandbox_account,false"]; len(names) != 1 || names[0] != "team_baz" {
@@ -0,0 +1,132 @@
+package generator
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+// The character sets IAM accepts for a role name and for each segment of its path.
+var (
+	roleNamePattern        = regexp.MustCompile(`^[\w+=,.@-]+$`)
+	rolePathSegmentPattern = regexp.MustCompile(`^[\x21-\x2e\x30-\x7e]+$`)
+)
+
+// roleRule is a RoleRule with its TagValue glob compiled and its RolePath normalized.
+type roleRule struct {
+	RoleRule
+	tagValue *regexp.Regexp
+}
+
+// roleResolver picks each account's role: the first of rules matching it, or else the
+// Options role.
+type roleResolver struct {
+	name  string
+	path  string
+	rules []roleRule
+}
+
+// compileRoles checks and compiles Options.RolePath and Options.RoleRules, so New can reject an
+// invalid one before any AWS call.
+func compileRoles(opts Options) (roleResolver, error) {
+	if opts.SSOStartURL != "" && (opts.RolePath != "" || len(opts.RoleRules) > 0) {
+		return roleResolver{}, fmt.Errorf("role paths and rules don't apply to SSO profiles")
+	}
+	path, err := normalizeRolePath(opts.RolePath)
+	if err != nil {
+		return roleResolver{}, err
+	}
+
+	resolver := roleResolver{name: opts.RoleName, path: path}
+	for i, rule := range opts.RoleRules {
+		compiled, err := compileRoleRule(rule)
+		if err != nil {
+			return roleResolver{}, fmt.Errorf("role rule %d: %w", i+1, err)
+		}
+		resolver.rules = append(resolver.rules, compiled)
+	}
+	return resolver, nil
+}
+
+func compileRoleRule(rule RoleRule) (roleRule, error) {
+	if rule.OU == "" && rule.TagKey == "" {
+		return roleRule{}, fmt.Errorf("must match an organizational unit or a tag")
+	}
+	if rule.TagKey == "" && rule.TagValue != "" {
+		return roleRule{}, fmt.Errorf("tag value %q needs a tag key", rule.TagValue)
+	}
+	if rule.RoleName == "" && rule.RolePath == "" {
+		return roleRule{}, fmt.Errorf("must set a role name or path")
+	}
+	if rule.RoleName != "" && (len(rule.RoleName) > 64 || !roleNamePattern.MatchString(rule.RoleName)) {
+		return roleRule{}, fmt.Errorf("role name %q must be up to 64 letters, digits or any of +=,.@-_", rule.RoleName)
+	}
+
+	compiled := roleRule{RoleRule: rule}
+	if rule.RolePath != "" {
+		path, err := normalizeRolePath(rule.RolePath)
+		if err != nil {
+			return roleRule{}, err
+		}
+		compiled.RolePath = path
+	}
+	if rule.TagKey != "" {
+		compiled.tagValue = compileGlob(rule.TagValue)
+	}
+	return compiled, nil
+}
+
+// normalizeRolePath returns path with a leading and a trailing slash, as IAM writes it, or "/"
+// if empty.
+func normalizeRolePath(path string) (string, error) {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return "/", nil
+	}
+	for segment := range strings.SplitSeq(trimmed, "/") {
+		if !rolePathSegmentPattern.MatchString(segment) {
+			return "", fmt.Errorf("role path %q must be slash-separated segments of printable ASCII characters, e.g. /security/", path)
+		}
+	}
+	normalized := "/" + trimmed + "/"
+	if len(normalized) > 512 {
+		return "", fmt.Errorf("role path %q must be up to 512 characters", path)
+	}
+	return normalized, nil
+}
+
+// ouIDs returns the organizational unit IDs the rules match, for validateOUs.
+func (r roleResolver) ouIDs() []string {
+	var ids []string
+	for _, rule := range r.rules {
+		if rule.OU != "" {
+			ids = append(ids, rule.OU)
+		}
+	}
+	return ids
+}
+
+// resolve returns the role name and path of the account with the given OU path and (split)
+// tags.
+func (r roleResolver) resolve(path []internalaws.OrganizationalUnit, tags map[string][]string) (name, rolePath string) {
+	for _, rule := range r.rules {
+		if rule.matches(path, tags) {
+			return cmp.Or(rule.RoleName, r.name), cmp.Or(rule.RolePath, r.path)
+		}
+	}
+	return r.name, r.path
+}
+
+func (rule roleRule) matches(path []internalaws.OrganizationalUnit, tags map[string][]string) bool {
+	if rule.OU != "" && !underAnyOU(path, []string{rule.OU}) {
+		return false
+	}
+	if rule.TagKey != "" && !slices.ContainsFunc(tags[rule.TagKey], rule.tagValue.MatchString) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func TestNormalizeRolePath(t *testing.T) {
+	tests := map[string]string{
+		"":                    "/",
+		"/":                   "/",
+		"security":            "/security/",
+		"/security/":          "/security/",
+		"/security/readers":   "/security/readers/",
+		"security/readers/":   "/security/readers/",
+		"/team-a/steam_pipe/": "/team-a/steam_pipe/",
+	}
+	for path, want := range tests {
+		got, err := normalizeRolePath(path)
+		if err != nil {
+			t.Errorf("normalizeRolePath(%q) unexpected error: %v", path, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("normalizeRolePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	for _, path := range []string{"/secu rity/", "/a//b/", "/é/", "/" + strings.Repeat("x", 511) + "/"} {
+		if _, err := normalizeRolePath(path); err == nil {
+			t.Errorf("normalizeRolePath(%q): expected an error", path)
+		}
+	}
+}
+
+func TestNew_InvalidRoles(t *testing.T) {
+	tests := map[string]Options{
+		"role path":            {RoleName: "my-role", RolePath: "/a b/"},
+		"rule without matcher": {RoleName: "my-role", RoleRules: []RoleRule{{RoleName: "other"}}},
+		"rule without role":    {RoleName: "my-role", RoleRules: []RoleRule{{OU: "ou-prod"}}},
+		"rule tag value only":  {RoleName: "my-role", RoleRules: []RoleRule{{TagValue: "sandbox", RoleName: "other"}}},
+		"rule role name":       {RoleName: "my-role", RoleRules: []RoleRule{{OU: "ou-prod", RoleName: "my role"}}},
+		"rule role path":       {RoleName: "my-role", RoleRules: []RoleRule{{OU: "ou-prod", RolePath: "/a//b/"}}},
+		"SSO profiles": {
+			RoleName:    "ReadOnlyAccess",
+			Region:      "eu-west-1",
+			SSOStartURL: "https://example.awsapps.com/start",
+			RolePath:    "/security/",
+		},
+	}
+	for name, opts := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := New(t.Context(), opts); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+// The first matching rule wins, a rule only setting one of the role name and path keeps the
+// other's default, and accounts matching no rule get the default role.
+func TestGenerator_Accounts_RoleRules(t *testing.T) {
+	client := ouFixture()
+	client.accounts[2].Tags = map[string]string{"env": "sandbox-1"}
+	client.accounts[3].Tags = map[string]string{"env": "sandbox-2"}
+	g := newTestGenerator(t, client, Options{
+		RoleName: "steampipe",
+		RolePath: "readers",
+		RoleRules: []RoleRule{
+			{OU: "ou-eu", RoleName: "eu-reader"},
+			{OU: "ou-prod", RoleName: "steampipe-reader", RolePath: "/security/"},
+			{TagKey: "env", TagValue: "sandbox-*", RolePath: "/"},
+			{OU: "ou-security", RoleName: "never-matched"},
+		},
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"111111111111": "arn:aws:iam::111111111111:role/security/steampipe-reader",
+		"222222222222": "arn:aws:iam::222222222222:role/readers/eu-reader",
+		"333333333333": "arn:aws:iam::333333333333:role/steampipe",
+		"444444444444": "arn:aws:iam::444444444444:role/steampipe",
+	}
+	for _, acc := range accounts {
+		if acc.RoleARN != want[acc.ID] {
+			t.Errorf("account %s: RoleARN = %q, want %q", acc.ID, acc.RoleARN, want[acc.ID])
+		}
+	}
+}
+
+func TestGenerator_Accounts_RoleRules_MultiValueTag(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo", Tags: map[string]string{"env": "prod:sandbox"}}},
+	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:  "steampipe",
+		TagSplit:  map[string]string{"env": ":"},
+		RoleRules: []RoleRule{{TagKey: "env", TagValue: "sandbox", RoleName: "sandbox-reader"}},
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := "arn:aws:iam::111111111111:role/sandbox-reader"; accounts[0].RoleARN != want {
+		t.Errorf("RoleARN = %q, want %q", accounts[0].RoleARN, want)
+	}
+}
+
+func TestGenerator_Accounts_RoleRules_UnknownOU(t *testing.T) {
+	g := newTestGenerator(t, ouFixture(), Options{
+		RoleName:  "steampipe",
+		RoleRules: []RoleRule{{OU: "ou-typo", RoleName: "other"}},
+	})
+
+	_, err := g.Accounts(t.Context())
+	if err == nil || !strings.Contains(err.Error(), "ou-typo") {
+		t.Errorf("error = %v, want one naming ou-typo", err)
+	}
+}
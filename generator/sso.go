@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+)
+
+// DefaultSSOSessionName is the name of the sso-session block SSO profiles share when
+// Options.SSOSessionName is empty.
+const DefaultSSOSessionName = "steampipe"
+
+// SSOSession is an IAM Identity Center sign-in, written as an "[sso-session name]" block of the
+// AWS config file and shared by every SSO profile referencing it: one `aws sso login
+// --sso-session name` signs them all in.
+type SSOSession struct {
+	Name     string
+	StartURL string
+	Region   string
+}
+
+// validateSSO checks the SSO options, if Options.SSOStartURL enables SSO profiles.
+func validateSSO(opts Options) error {
+	if opts.SSOStartURL == "" {
+		if len(opts.PermissionSets) > 0 {
+			return fmt.Errorf("permission sets only apply to SSO profiles, which need an SSO start URL")
+		}
+		return nil
+	}
+	if len(opts.PermissionSets) > 0 && opts.RoleName != "" {
+		return fmt.Errorf("role name %q and permission sets are mutually exclusive", opts.RoleName)
+	}
+	if slices.Contains(opts.PermissionSets, "") {
+		return fmt.Errorf("permission sets must not be empty")
+	}
+	u, err := url.Parse(opts.SSOStartURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("SSO start URL %q must be an https:// URL, e.g. https://my-sso-portal.awsapps.com/start", opts.SSOStartURL)
+	}
+	if cmp.Or(opts.SSORegion, opts.Region) == "" {
+		return fmt.Errorf("SSO profiles need an SSO region")
+	}
+	return validateProfileName("SSO session name", cmp.Or(opts.SSOSessionName, DefaultSSOSessionName))
+}
+
+// assignPermissionSets sets each account's SSORoleName to the first of Options.PermissionSets
+// provisioned to it, and returns the accounts that have one.
+func (g *generator) assignPermissionSets(ctx context.Context, accounts []Account) ([]Account, error) {
+	if g.ssoAdmin == nil {
+		return nil, fmt.Errorf("permission sets need an IAM Identity Center client")
+	}
+
+	ids := make([]string, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.ID)
+	}
+	provisioned, err := g.ssoAdmin.ListPermissionSets(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("fetching provisioned permission sets: %w", err)
+	}
+
+	assigned := accounts[:0]
+	for _, acc := range accounts {
+		i := slices.IndexFunc(g.opts.PermissionSets, func(name string) bool {
+			return slices.Contains(provisioned[acc.ID], name)
+		})
+		if i < 0 {
+			continue
+		}
+		acc.SSORoleName = g.opts.PermissionSets[i]
+		assigned = append(assigned, acc)
+	}
+	return assigned, nil
+}
+
+// ssoSessions returns the SSO sessions of accounts, sorted by name. Accounts referencing the
+// same session name must agree on its start URL and region.
+func ssoSessions(accounts []Account) ([]SSOSession, error) {
+	sessions := make(map[string]SSOSession)
+	for _, acc := range accounts {
+		if acc.SSOStartURL == "" {
+			continue
+		}
+		session := SSOSession{Name: acc.SSOSession, StartURL: acc.SSOStartURL, Region: acc.SSORegion}
+		if existing, ok := sessions[session.Name]; ok && existing != session {
+			return nil, fmt.Errorf("account %s: SSO session %q is also used with start URL %q and region %q", acc.ID, session.Name, existing.StartURL, existing.Region)
+		}
+		sessions[session.Name] = session
+	}
+
+	list := make([]SSOSession, 0, len(sessions))
+	for _, name := range slices.Sorted(maps.Keys(sessions)) {
+		list = append(list, sessions[name])
+	}
+	return list, nil
+}
@@ -0,0 +1,207 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func TestValidateSSO(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "disabled", opts: Options{}},
+		{name: "region from Region", opts: Options{SSOStartURL: "https://example.awsapps.com/start", Region: "eu-west-1"}},
+		{name: "SSO region", opts: Options{SSOStartURL: "https://example.awsapps.com/start", SSORegion: "eu-west-1"}},
+		{name: "no region", opts: Options{SSOStartURL: "https://example.awsapps.com/start"}, wantErr: true},
+		{name: "http start URL", opts: Options{SSOStartURL: "http://example.awsapps.com/start", Region: "eu-west-1"}, wantErr: true},
+		{name: "start URL without scheme", opts: Options{SSOStartURL: "example.awsapps.com/start", Region: "eu-west-1"}, wantErr: true},
+		{name: "session name with whitespace", opts: Options{SSOStartURL: "https://example.awsapps.com/start", Region: "eu-west-1", SSOSessionName: "my session"}, wantErr: true},
+		{name: "permission sets", opts: Options{SSOStartURL: "https://example.awsapps.com/start", Region: "eu-west-1", PermissionSets: []string{"ReadOnlyAccess"}}},
+		{name: "permission sets without SSO", opts: Options{PermissionSets: []string{"ReadOnlyAccess"}}, wantErr: true},
+		{name: "permission sets and role", opts: Options{SSOStartURL: "https://example.awsapps.com/start", Region: "eu-west-1", RoleName: "x", PermissionSets: []string{"ReadOnlyAccess"}}, wantErr: true},
+		{name: "empty permission set", opts: Options{SSOStartURL: "https://example.awsapps.com/start", Region: "eu-west-1", PermissionSets: []string{""}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSSO(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSSO() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNew_SSOWithCredentialSource(t *testing.T) {
+	_, err := New(t.Context(), Options{
+		RoleName:         "ReadOnlyAccess",
+		Region:           "eu-west-1",
+		CredentialSource: "Environment",
+		SSOStartURL:      "https://example.awsapps.com/start",
+	})
+	if err == nil {
+		t.Fatal("expected an error for an SSO profile with a credential source")
+	}
+}
+
+func TestGenerator_Accounts_SSO(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{{ID: "111111111111", Name: "team-foo"}},
+	}
+
+	g := newTestGenerator(t, client, Options{
+		RoleName:    "ReadOnlyAccess",
+		Region:      "eu-west-1",
+		SSOStartURL: "https://example.awsapps.com/start",
+		SSORegion:   "us-east-1",
+	})
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := accounts[0]
+	want := Account{
+		SSOStartURL: "https://example.awsapps.com/start",
+		SSORegion:   "us-east-1",
+		SSOSession:  DefaultSSOSessionName,
+		SSORoleName: "ReadOnlyAccess",
+	}
+	if got.SSOStartURL != want.SSOStartURL || got.SSORegion != want.SSORegion || got.SSOSession != want.SSOSession || got.SSORoleName != want.SSORoleName {
+		t.Errorf("SSO fields = %q, %q, %q, %q, want %q, %q, %q, %q",
+			got.SSOStartURL, got.SSORegion, got.SSOSession, got.SSORoleName,
+			want.SSOStartURL, want.SSORegion, want.SSOSession, want.SSORoleName)
+	}
+	if got.RoleARN != "" {
+		t.Errorf("RoleARN = %q, want none for an SSO profile", got.RoleARN)
+	}
+}
+
+// fakeSSOAdminClient is an in-memory SSOAdminClient.
+type fakeSSOAdminClient struct {
+	permissionSets map[string][]string
+	err            error
+
+	accountIDs []string
+}
+
+func (f *fakeSSOAdminClient) ListPermissionSets(ctx context.Context, accountIDs []string) (map[string][]string, error) {
+	f.accountIDs = accountIDs
+	return f.permissionSets, f.err
+}
+
+func TestGenerator_Accounts_PermissionSets(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "team-foo"},
+			{ID: "222222222222", Name: "team-bar"},
+			{ID: "333333333333", Name: "team-baz"},
+			{ID: "444444444444", Name: "excluded"},
+		},
+	}
+	ssoAdmin := &fakeSSOAdminClient{permissionSets: map[string][]string{
+		"111111111111": {"AdministratorAccess", "ReadOnlyAccess", "ViewOnlyAccess"},
+		"222222222222": {"ViewOnlyAccess"},
+		"333333333333": {"AdministratorAccess"},
+	}}
+
+	g := newTestGenerator(t, client, Options{
+		Region:          "eu-west-1",
+		SSOStartURL:     "https://example.awsapps.com/start",
+		PermissionSets:  []string{"ReadOnlyAccess", "ViewOnlyAccess"},
+		ExcludeAccounts: []string{"444444444444"},
+	})
+	g.ssoAdmin = ssoAdmin
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, acc := range accounts {
+		got[acc.ID] = acc.SSORoleName
+	}
+	// team-baz has none of the permission sets, so it's left out.
+	want := map[string]string{"111111111111": "ReadOnlyAccess", "222222222222": "ViewOnlyAccess"}
+	if !maps.Equal(got, want) {
+		t.Errorf("permission sets = %v, want %v", got, want)
+	}
+	if slices.Contains(ssoAdmin.accountIDs, "444444444444") {
+		t.Errorf("permission sets looked up for filtered out account: %v", ssoAdmin.accountIDs)
+	}
+
+	ssoAdmin.err = errors.New("boom")
+	if _, err := g.Accounts(t.Context()); err == nil {
+		t.Fatal("expected an error when the permission sets can't be fetched")
+	}
+}
+
+func TestRenderConfig_SSO(t *testing.T) {
+	sso := func(id, name string) Account {
+		return Account{
+			ID:          id,
+			Name:        name,
+			SSOStartURL: "https://example.awsapps.com/start",
+			SSORegion:   "us-east-1",
+			SSOSession:  "steampipe",
+			SSORoleName: "ReadOnlyAccess",
+		}
+	}
+	accounts := []Account{sso("222222222222", "team_bar"), sso("111111111111", "team_foo")}
+
+	var buf bytes.Buffer
+	if err := RenderConfig(&buf, accounts, ConfigOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `[sso-session steampipe]
+sso_start_url = https://example.awsapps.com/start
+sso_region = us-east-1
+sso_registration_scopes = sso:account:access
+
+[profile team_bar]
+sso_session = steampipe
+sso_account_id = 222222222222
+sso_role_name = ReadOnlyAccess
+sso_region = us-east-1
+sso_start_url = https://example.awsapps.com/start
+
+[profile team_foo]
+sso_session = steampipe
+sso_account_id = 111111111111
+sso_role_name = ReadOnlyAccess
+sso_region = us-east-1
+sso_start_url = https://example.awsapps.com/start
+
+`
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestRenderConfig_SSOSessionConflict(t *testing.T) {
+	accounts := []Account{
+		{ID: "111111111111", Name: "team_foo", SSOStartURL: "https://a.awsapps.com/start", SSORegion: "us-east-1", SSOSession: "steampipe"},
+		{ID: "222222222222", Name: "team_bar", SSOStartURL: "https://b.awsapps.com/start", SSORegion: "us-east-1", SSOSession: "steampipe"},
+	}
+
+	if err := RenderConfig(&bytes.Buffer{}, accounts, ConfigOptions{}); err == nil {
+		t.Fatal("expected an error for one SSO session with two start URLs")
+	}
+}
+
+func TestRenderCredentials_SSO(t *testing.T) {
+	accounts := []Account{{ID: "111111111111", Name: "team_foo", SSOStartURL: "https://example.awsapps.com/start"}}
+
+	if err := RenderCredentials(&bytes.Buffer{}, accounts); err == nil {
+		t.Fatal("expected an error for an SSO profile in the credentials file")
+	}
+}
@@ -0,0 +1,279 @@
+package generator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// tagFilter is a parsed Options.TagFilter expression. It's evaluated against an account's tags
+// after splitTagValue, so a tag listed in Options.TagSplit matches if any one of its values
+// does.
+type tagFilter interface {
+	match(tags map[string][]string) bool
+}
+
+type tagFilterAnd struct{ left, right tagFilter }
+
+func (f tagFilterAnd) match(tags map[string][]string) bool {
+	return f.left.match(tags) && f.right.match(tags)
+}
+
+type tagFilterOr struct{ left, right tagFilter }
+
+func (f tagFilterOr) match(tags map[string][]string) bool {
+	return f.left.match(tags) || f.right.match(tags)
+}
+
+type tagFilterNot struct{ operand tagFilter }
+
+func (f tagFilterNot) match(tags map[string][]string) bool {
+	return !f.operand.match(tags)
+}
+
+// tagFilterEquals matches if any value of key matches the value glob.
+type tagFilterEquals struct {
+	key   string
+	value *regexp.Regexp
+}
+
+func (f tagFilterEquals) match(tags map[string][]string) bool {
+	for _, value := range tags[f.key] {
+		if f.value.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+type tagFilterExists struct{ key string }
+
+func (f tagFilterExists) match(tags map[string][]string) bool {
+	_, ok := tags[f.key]
+	return ok
+}
+
+// parseTagFilter parses a tag filter expression. It returns a nil tagFilter, matching every
+// account, for an empty expression. The grammar, with keywords matched case-insensitively, is:
+//
+//	expr       = and { "OR" and }
+//	and        = unary { "AND" unary }
+//	unary      = "NOT" unary | "(" expr ")" | comparison
+//	comparison = key "=" value | key "!=" value | key "exists"
+//
+// A value may use "*" and "?" globs (see compileGlob). key!=value is the negation of
+// key=value, so it also matches accounts without the key at all. A key or value containing
+// whitespace, parentheses, "=", "!" or a '"' must be double-quoted, with '"' and '\' escaped
+// by a backslash inside the quotes.
+func parseTagFilter(expr string) (tagFilter, error) {
+	tokens, err := lexTagFilter(expr)
+	if err != nil {
+		return nil, fmt.Errorf("tag filter: %w", err)
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	p := &tagFilterParser{tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("tag filter: %w", err)
+	}
+	if tok := p.peek(); tok.kind != tagTokenEOF {
+		return nil, fmt.Errorf("tag filter: unexpected %s at position %d", tok, tok.pos)
+	}
+	return filter, nil
+}
+
+type tagTokenKind int
+
+const (
+	tagTokenEOF tagTokenKind = iota
+	tagTokenWord
+	tagTokenString
+	tagTokenEquals
+	tagTokenNotEquals
+	tagTokenLParen
+	tagTokenRParen
+)
+
+type tagToken struct {
+	kind tagTokenKind
+	text string
+	pos  int
+}
+
+func (t tagToken) String() string {
+	if t.kind == tagTokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// isKeyword reports whether t is the unquoted keyword kw. Quoting a word always makes it a
+// plain key or value.
+func (t tagToken) isKeyword(kw string) bool {
+	return t.kind == tagTokenWord && strings.EqualFold(t.text, kw)
+}
+
+// lexTagFilter splits expr into tokens, always ending with a tagTokenEOF. Positions are
+// 1-based byte offsets, for error messages.
+func lexTagFilter(expr string) ([]tagToken, error) {
+	var tokens []tagToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case unicode.IsSpace(rune(c)):
+			i++
+		case c == '(':
+			tokens = append(tokens, tagToken{kind: tagTokenLParen, text: "(", pos: i + 1})
+			i++
+		case c == ')':
+			tokens = append(tokens, tagToken{kind: tagTokenRParen, text: ")", pos: i + 1})
+			i++
+		case c == '=':
+			tokens = append(tokens, tagToken{kind: tagTokenEquals, text: "=", pos: i + 1})
+			i++
+		case c == '!':
+			if i+1 >= len(expr) || expr[i+1] != '=' {
+				return nil, fmt.Errorf(`unexpected "!" at position %d, did you mean "!=" or NOT?`, i+1)
+			}
+			tokens = append(tokens, tagToken{kind: tagTokenNotEquals, text: "!=", pos: i + 1})
+			i += 2
+		case c == '"':
+			text, n, err := lexQuoted(expr[i:])
+			if err != nil {
+				return nil, fmt.Errorf("%w at position %d", err, i+1)
+			}
+			tokens = append(tokens, tagToken{kind: tagTokenString, text: text, pos: i + 1})
+			i += n
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\n\r()=!\"", rune(expr[i])) {
+				i++
+			}
+			tokens = append(tokens, tagToken{kind: tagTokenWord, text: expr[start:i], pos: start + 1})
+		}
+	}
+	return append(tokens, tagToken{kind: tagTokenEOF, pos: len(expr) + 1}), nil
+}
+
+// lexQuoted reads the double-quoted string at the start of s, returning its unescaped text
+// and the number of bytes consumed, including both quotes.
+func lexQuoted(s string) (string, int, error) {
+	var text strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return text.String(), i + 1, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, fmt.Errorf("unterminated quoted string")
+			}
+			i++
+			text.WriteByte(s[i])
+		default:
+			text.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated quoted string")
+}
+
+type tagFilterParser struct {
+	tokens []tagToken
+	pos    int
+}
+
+func (p *tagFilterParser) peek() tagToken { return p.tokens[p.pos] }
+
+func (p *tagFilterParser) next() tagToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tagTokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *tagFilterParser) parseOr() (tagFilter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = tagFilterOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *tagFilterParser) parseAnd() (tagFilter, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = tagFilterAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *tagFilterParser) parseUnary() (tagFilter, error) {
+	tok := p.peek()
+	switch {
+	case tok.isKeyword("NOT"):
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return tagFilterNot{operand: operand}, nil
+	case tok.kind == tagTokenLParen:
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tagTokenRParen {
+			return nil, fmt.Errorf(`expected ")" to close "(" at position %d, got %s`, tok.pos, closing)
+		}
+		return inner, nil
+	default:
+		return p.parseComparison()
+	}
+}
+
+func (p *tagFilterParser) parseComparison() (tagFilter, error) {
+	key := p.next()
+	if (key.kind != tagTokenWord && key.kind != tagTokenString) ||
+		key.isKeyword("AND") || key.isKeyword("OR") || key.isKeyword("exists") {
+		return nil, fmt.Errorf("expected a tag key at position %d, got %s", key.pos, key)
+	}
+
+	op := p.next()
+	switch {
+	case op.isKeyword("exists"):
+		return tagFilterExists{key: key.text}, nil
+	case op.kind == tagTokenEquals, op.kind == tagTokenNotEquals:
+		value := p.next()
+		if value.kind != tagTokenWord && value.kind != tagTokenString {
+			return nil, fmt.Errorf("expected a value after %q at position %d, got %s", op.text, value.pos, value)
+		}
+		equals := tagFilterEquals{key: key.text, value: compileGlob(value.text)}
+		if op.kind == tagTokenNotEquals {
+			return tagFilterNot{operand: equals}, nil
+		}
+		return equals, nil
+	default:
+		return nil, fmt.Errorf(`expected "=", "!=" or "exists" after tag key %q at position %d, got %s`, key.text, op.pos, op)
+	}
+}
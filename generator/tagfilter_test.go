@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"testing"
+
+	internalaws "github.com/unicrons/steampipe-config-generator/internal/aws"
+)
+
+func TestParseTagFilter_Match(t *testing.T) {
+	tags := map[string][]string{
+		"steampipe":  {"enabled"},
+		"env":        {"prod"},
+		"team":       {"frontend", "backend"},
+		"cost:owner": {"platform/eu"},
+		"note":       {"has spaces"},
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`steampipe=enabled`, true},
+		{`steampipe=disabled`, false},
+		{`steampipe = enabled`, true},
+		{`env!=sandbox`, true},
+		{`env!=prod`, false},
+		{`missing!=anything`, true},
+		{`env exists`, true},
+		{`missing exists`, false},
+		{`env EXISTS`, true},
+		{`steampipe=enabled AND NOT env=sandbox`, true},
+		{`steampipe=enabled and not env=prod`, false},
+		{`env=sandbox OR env=prod`, true},
+		{`env=sandbox OR env=dev`, false},
+		{`env=dev OR env=prod AND steampipe=disabled`, false},
+		{`(env=dev OR env=prod) AND steampipe=enabled`, true},
+		{`NOT (env=dev OR env=staging)`, true},
+		{`NOT NOT env=prod`, true},
+		{`env=pr*`, true},
+		{`env=p?od`, true},
+		{`env=*x*`, false},
+		{`team=backend`, true},
+		{`team=front*`, true},
+		{`team!=backend`, false},
+		{`cost:owner=platform/*`, true},
+		{`note="has spaces"`, true},
+		{`"note"="has *"`, true},
+		{`env="NOT"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			filter, err := parseTagFilter(tt.expr)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := filter.match(tags); got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTagFilter_Empty(t *testing.T) {
+	for _, expr := range []string{"", "   "} {
+		filter, err := parseTagFilter(expr)
+		if err != nil {
+			t.Fatalf("parseTagFilter(%q): unexpected error: %v", expr, err)
+		}
+		if filter != nil {
+			t.Errorf("parseTagFilter(%q) = %v, want nil (match everything)", expr, filter)
+		}
+	}
+}
+
+func TestParseTagFilter_Errors(t *testing.T) {
+	for _, expr := range []string{
+		`env`,
+		`env=`,
+		`env==prod`,
+		`=prod`,
+		`env!prod`,
+		`env=prod AND`,
+		`env=prod OR OR env=dev`,
+		`(env=prod`,
+		`env=prod)`,
+		`env=prod env=dev`,
+		`note="unterminated`,
+		`AND exists`,
+		`NOT`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			if _, err := parseTagFilter(expr); err == nil {
+				t.Errorf("expected an error for %q", expr)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidTagFilter(t *testing.T) {
+	_, err := New(t.Context(), Options{RoleName: "my-role", TagFilter: "env=prod AND"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid tag filter")
+	}
+}
+
+// The filter is evaluated against already-split values, so a multi-value tag from --tagSplit
+// matches per value rather than only on its raw, combined value.
+func TestGenerator_Accounts_TagFilterWithTagSplit(t *testing.T) {
+	client := &fakeOrganizationsClient{
+		accounts: []internalaws.Account{
+			{ID: "111111111111", Name: "account-a", Tags: map[string]string{"steampipe": "enabled", "team": "frontend:backend"}},
+			{ID: "222222222222", Name: "account-b", Tags: map[string]string{"steampipe": "enabled", "team": "frontend", "env": "sandbox"}},
+			{ID: "333333333333", Name: "account-c", Tags: map[string]string{"team": "backend"}},
+		},
+	}
+	g := newTestGenerator(t, client, Options{
+		RoleName:  "my-role",
+		TagSplit:  map[string]string{"team": ":"},
+		TagFilter: "steampipe=enabled AND team=backend AND NOT env=sandbox",
+	})
+
+	accounts, err := g.Accounts(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := accountNames(accounts), []string{"account_a"}; !equalUnordered(got, want) {
+		t.Errorf("accounts = %v, want %v", got, want)
+	}
+}
@@ -129,10 +129,7 @@ func TestGenerator_Accounts_MultiValueTagAggregation(t *testing.T) {
 			{ID: "222222222222", Name: "account-b", Tags: map[string]string{"team": "backend"}},
 		},
 	}
-	g := &generator{
-		client: client,
-		opts:   Options{RoleName: "my-role", TagSplit: map[string]string{"team": ":"}},
-	}
+	g := newTestGenerator(t, client, Options{RoleName: "my-role", TagSplit: map[string]string{"team": ":"}})
 
 	accounts, err := g.Accounts(t.Context())
 	if err != nil {
@@ -1,17 +1,75 @@
 package generator
 
+import "time"
+
 // Account is an AWS Organizations account together with the data needed to render its
-// Steampipe connection and credentials entries. Tags maps each tag key to its value(s) - a
-// single-element slice for tags with no configured split, or multiple elements for tags
-// listed in Options.TagSplit.
+// Steampipe connection and credentials entries. Every field is available to a custom
+// connections template.
 type Account struct {
-	Name             string
-	RoleARN          string
+	// ID is the AWS account ID.
+	ID string
+	// Name is the account's profile name, and its connection name once prefixed with "aws_":
+	// its normalized AWS account name by default (see Options.NamingScheme).
+	Name string
+	// OriginalName is the account name as set in AWS Organizations, before any normalization.
+	OriginalName string
+	// Email is the email address of the account's root user.
+	Email string
+	// ARN is the account's AWS Organizations ARN.
+	ARN string
+	// Partition is the AWS partition the account is in, such as "aws", "aws-us-gov" or
+	// "aws-cn". Every ARN generated for the account, like RoleARN, is in it.
+	Partition string
+	// JoinedTimestamp is when the account became part of the organization, and JoinedMethod
+	// how: "INVITED" or "CREATED".
+	JoinedTimestamp time.Time
+	JoinedMethod    string
+	RoleARN         string
+	// CredentialSource and SourceProfile are where the profile gets the credentials to assume
+	// RoleARN with: only one of them is set, unless it's an SSO profile.
 	CredentialSource string
-	ImportSchema     string
-	DefaultRegion    string
-	TargetRegions    []string
-	Tags             map[string][]string
+	SourceProfile    string
+	// AssumeRoleOptions are the profile's optional assume-role settings: Options.AssumeRole,
+	// overridden per account. Empty for SSO profiles.
+	AssumeRoleOptions
+	// SSOStartURL, SSORegion, SSOSession and SSORoleName are only set for an IAM Identity
+	// Center (SSO) profile, which signs in through the SSOSession sso-session and gets the
+	// credentials of the SSORoleName permission set in the account, instead of assuming
+	// RoleARN, then empty.
+	SSOStartURL   string
+	SSORegion     string
+	SSOSession    string
+	SSORoleName   string
+	ImportSchema  string
+	DefaultRegion string
+	// TargetRegions are the regions of the account's connection: Options.TargetRegions, or
+	// those enabled in the account with Options.RegionDiscovery.
+	TargetRegions []string
+	// Tags maps each tag key to its value(s) - a single-element slice for tags with no
+	// configured split, or multiple elements for tags listed in Options.TagSplit.
+	Tags map[string][]string
+	// OU is the account's direct parent: an organizational unit, or the organization root.
+	OU OrganizationalUnit
+	// OUPath is the account's organizational unit ancestry, ordered from the organization root
+	// down to its direct parent, OU.
+	OUPath []OrganizationalUnit
+	// Overrides holds the values of the account's override tags (see
+	// Options.OverrideTagPrefix), by OverrideTag* key, or is nil if it has none.
+	Overrides map[string]string
+	// UnknownRegions lists the regions the account's override tags set that aren't known to
+	// this build, most likely newer ones, and are used as is.
+	UnknownRegions []string
+	// CollidedName is set if the account's name collided with another account's and was
+	// resolved per Options.NameCollision: it holds the shared name, while Name holds the
+	// account's final, unique name.
+	CollidedName string
+}
+
+// OrganizationalUnit is an organizational unit, or the organization root, in an Account's
+// OUPath.
+type OrganizationalUnit struct {
+	ID   string
+	Name string
 }
 
 // Options configures a Generator.
@@ -21,19 +79,137 @@ type Options struct {
 	// Region is the AWS region used both to call AWS Organizations and as each account's
 	// DefaultRegion.
 	Region string
-	// RoleName is the IAM role name used to build each account's RoleARN.
+	// RoleName is the IAM role name used to build each account's RoleARN, or for SSO profiles
+	// the permission set name written as their sso_role_name.
 	RoleName string
+	// RolePath is the IAM path of RoleName, e.g. "/security/"; "/" if empty. Its leading and
+	// trailing slashes are optional.
+	RolePath string
+	// RoleRules picks the role of the accounts they match instead of RoleName and RolePath:
+	// the first rule matching an account wins. They don't apply to SSO profiles.
+	RoleRules []RoleRule
 	// CredentialSource is the AWS credential source written for each account.
 	CredentialSource string
+	// SourceProfile, if set, is the named profile written as each account's source_profile
+	// instead of a credential_source, e.g. an SSO profile to chain the role assumption from.
+	// It's mutually exclusive with CredentialSource, and mustn't be one of the generated
+	// profiles.
+	SourceProfile string
+	// SSOStartURL, if set, generates IAM Identity Center (SSO) profiles instead of assume-role
+	// ones: each account's profile gets the RoleName permission set through a shared
+	// sso-session, named SSOSessionName (DefaultSSOSessionName if empty), which signs in at
+	// SSOStartURL in SSORegion (Region if empty). It's mutually exclusive with
+	// CredentialSource and SourceProfile. SSO profiles can only be rendered by RenderConfig.
+	SSOStartURL    string
+	SSORegion      string
+	SSOSessionName string
+	// AssumeRole sets the optional settings of every assume-role profile, and AccountAssumeRole
+	// overrides them for the account with that ID: each of its non-zero fields replaces
+	// AssumeRole's. They don't apply to SSO profiles.
+	AssumeRole        AssumeRoleOptions
+	AccountAssumeRole map[string]AssumeRoleOptions
+	// OverrideTagPrefix, if set, lets account tags override settings for their account: a tag
+	// named OverrideTagPrefix followed by one of the OverrideTag* keys, e.g.
+	// "steampipe:external_id" or "steampipe:regions", overrides the matching setting, over
+	// AccountAssumeRole, and "steampipe:skip" set to true leaves the account out. Each
+	// account's override tags are reported in its Overrides.
+	OverrideTagPrefix string
+	// PermissionSets, if set, replaces RoleName for SSO profiles: each account's profile gets
+	// the first of these permission sets, by name, that IAM Identity Center has provisioned to
+	// the account, and accounts with none of them are left out.
+	PermissionSets []string
 	// ImportSchema controls the import_schema value written for each account.
 	ImportSchema string
 	// TargetRegions is the list of regions written for each account (["*"] for all).
 	TargetRegions []string
-	// SkipOUs lists organizational unit IDs whose accounts are excluded from the result.
+	// RegionDiscovery, if set, replaces each account's TargetRegions with the regions enabled
+	// in it, per AWS Account Management, that match one of TargetRegions: listed with the
+	// organization credentials (RegionDiscoveryOrganization), or from inside each account by
+	// assuming its role (RegionDiscoveryMemberRole).
+	RegionDiscovery string
+	// IncludeOUs, if set, restricts the result to accounts under one of these organizational
+	// unit (or root) IDs, including accounts in any OU nested below them.
+	IncludeOUs []string
+	// SkipOUs lists organizational unit IDs whose accounts are excluded from the result,
+	// including accounts in any OU nested below them. A skip wins over an include.
 	SkipOUs []string
 	// TagSplit maps a tag key to the set of delimiter characters (e.g. ":-") its value
 	// should be split on. Tags whose key isn't listed here keep their raw value, unchanged.
 	// Only characters from AWS's supported tag character set are valid delimiters:
 	// . : + = @ _ / -
 	TagSplit map[string]string
+	// TagFilter, if set, restricts the result to accounts whose tags match this expression,
+	// e.g. `steampipe=enabled AND NOT env=sandbox`. It supports key=value, key!=value and
+	// "key exists" comparisons, AND/OR/NOT with parentheses, and "*" and "?" globs in values.
+	// Multi-value tags (see TagSplit) match if any one of their values does.
+	TagFilter string
+	// IncludeAccounts, if set, restricts the result to accounts matching one of these
+	// patterns, and ExcludeAccounts drops accounts matching any of them; an exclude wins over
+	// an include. Each pattern is matched against the account ID, its raw Organizations name
+	// and its normalized name: a pattern prefixed with "re:" is a regular expression, anything
+	// else (including a plain account ID) a glob where "*" and "?" are wildcards.
+	IncludeAccounts []string
+	ExcludeAccounts []string
+	// NameCollision selects how accounts whose normalized names collide are handled:
+	// NameCollisionFail (the default if empty), NameCollisionSuffixID or
+	// NameCollisionSuffixCounter.
+	NameCollision string
+	// NamingScheme selects how each account's Name, used as both its credentials profile name
+	// and (prefixed with "aws_") its connection name, is built: NamingSchemeName (the default
+	// if empty), NamingSchemeID, NamingSchemeNameID, NamingSchemeTagPrefix followed by a tag
+	// key, or a Go template executed with the Account, e.g. `{{ .Name }}_{{ .ID }}`. The
+	// result is always normalized into a valid connection identifier.
+	NamingScheme string
+}
+
+// AssumeRoleOptions are the optional settings of an assume-role profile, each written only if
+// set, except RoleSessionName, which defaults to DefaultRoleSessionName.
+type AssumeRoleOptions struct {
+	// ExternalID is the external ID the role's trust policy requires, if any.
+	ExternalID string
+	// DurationSeconds is how long the role's credentials last, between MinDurationSeconds and
+	// MaxDurationSeconds, or the role's default if zero.
+	DurationSeconds int
+	// RoleSessionName names the role sessions, as shown in CloudTrail.
+	RoleSessionName string
+	// MFASerial is the ARN, or serial number, of the MFA device to authenticate with before
+	// assuming the role.
+	MFASerial string
+}
+
+// RoleRule sets the role of the accounts under OU, or tagged TagKey with a value matching
+// TagValue, or both if both are set.
+type RoleRule struct {
+	// OU is an organizational unit (or root) ID, matching the accounts anywhere in its
+	// subtree.
+	OU string
+	// TagKey and TagValue match the accounts with a TagKey tag value matching TagValue, a glob
+	// where "*" and "?" are wildcards. Multi-value tags (see Options.TagSplit) match if any one
+	// of their values does.
+	TagKey   string
+	TagValue string
+	// RoleName and RolePath replace Options.RoleName and Options.RolePath for the matched
+	// accounts. At least one must be set; the other keeps its Options value.
+	RoleName string
+	RolePath string
+}
+
+// ConnectionsOptions configures RenderConnections.
+type ConnectionsOptions struct {
+	// AggregateByTag lists tag keys to generate aggregator connections for: one per distinct
+	// value of each key, holding every account with that value. Multi-value tags (see
+	// Options.TagSplit) contribute one aggregator per value.
+	AggregateByTag []string
+	// AggregateByOU generates one aggregator connection per organizational unit, holding every
+	// account in the OU's subtree.
+	AggregateByOU bool
+	// AggregatorPrefix prefixes every generated aggregator name. DefaultAggregatorPrefix is
+	// used if empty.
+	AggregatorPrefix string
+}
+
+// ConfigOptions configures RenderConfig.
+type ConfigOptions struct {
+	// IncludeRegion sets each profile's region to its account's DefaultRegion.
+	IncludeRegion bool
 }
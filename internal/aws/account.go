@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/account"
+	"github.com/aws/aws-sdk-go-v2/service/account/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// maxConcurrentRegionFetches bounds the number of concurrent per-account Account Management
+// calls, to stay under its rate limits.
+const maxConcurrentRegionFetches = 8
+
+// RegionsTarget is an account to list the enabled regions of.
+type RegionsTarget struct {
+	AccountID string
+	// RoleARN, if set, is assumed, with ExternalID if set, to list the regions from inside the
+	// account. Otherwise they're listed with the client's own credentials, which must be the
+	// organization's management account's or an Account Management delegated administrator's.
+	RoleARN    string
+	ExternalID string
+}
+
+// accountAPI is the subset of the Account Management SDK client this package calls.
+type accountAPI interface {
+	account.ListRegionsAPIClient
+}
+
+type accountClient struct {
+	client accountAPI
+	// callerAccountID returns the ID of the account client's credentials are from.
+	callerAccountID func(ctx context.Context) (string, error)
+	// memberClient returns a client assuming roleARN, with externalID if set.
+	memberClient func(roleARN, externalID string) accountAPI
+}
+
+// NewAccountClient returns a client backed by the real AWS SDK, with the same retry policy as
+// NewOrganizationsClient. It satisfies generator.AccountClient.
+func NewAccountClient(cfg awssdk.Config) *accountClient {
+	cfg.Retryer = throttledRetryer
+	stsClient := sts.NewFromConfig(cfg)
+
+	var callerOnce sync.Once
+	var callerID string
+	var callerErr error
+	return &accountClient{
+		client: account.NewFromConfig(cfg),
+		callerAccountID: func(ctx context.Context) (string, error) {
+			callerOnce.Do(func() {
+				out, err := stsClient.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+				if err != nil {
+					callerErr = fmt.Errorf("getting caller identity: %w", err)
+					return
+				}
+				callerID = awssdk.ToString(out.Account)
+			})
+			return callerID, callerErr
+		},
+		memberClient: func(roleARN, externalID string) accountAPI {
+			provider := stscreds.NewAssumeRoleProvider(stsClient, roleARN, func(o *stscreds.AssumeRoleOptions) {
+				o.RoleSessionName = "steampipeConfigGenerator"
+				if externalID != "" {
+					o.ExternalID = &externalID
+				}
+			})
+			memberCfg := cfg.Copy()
+			memberCfg.Credentials = awssdk.NewCredentialsCache(provider)
+			return account.NewFromConfig(memberCfg)
+		},
+	}
+}
+
+// ListEnabledRegions returns the regions enabled in each of targets, by account ID, listing
+// accounts concurrently bounded by maxConcurrentRegionFetches.
+func (c *accountClient) ListEnabledRegions(ctx context.Context, targets []RegionsTarget) (map[string][]string, error) {
+	regions := make([][]string, len(targets))
+	err := fetchConcurrently(ctx, len(targets), maxConcurrentRegionFetches, func(ctx context.Context, i int) error {
+		list, err := c.listEnabledRegions(ctx, targets[i])
+		if err != nil {
+			return fmt.Errorf("account %s: listing enabled regions: %w", targets[i].AccountID, err)
+		}
+		regions[i] = list
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := make(map[string][]string, len(targets))
+	for i, target := range targets {
+		enabled[target.AccountID] = regions[i]
+	}
+	return enabled, nil
+}
+
+func (c *accountClient) listEnabledRegions(ctx context.Context, target RegionsTarget) ([]string, error) {
+	client := c.client
+	input := &account.ListRegionsInput{
+		RegionOptStatusContains: []types.RegionOptStatus{types.RegionOptStatusEnabled, types.RegionOptStatusEnabledByDefault},
+	}
+	if target.RoleARN != "" {
+		client = c.memberClient(target.RoleARN, target.ExternalID)
+	} else {
+		// The management account can't name itself, only list its own regions.
+		caller, err := c.callerAccountID(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if target.AccountID != caller {
+			input.AccountId = &target.AccountID
+		}
+	}
+
+	var regions []string
+	paginator := account.NewListRegionsPaginator(client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, region := range page.Regions {
+			regions = append(regions, awssdk.ToString(region.RegionName))
+		}
+	}
+	return regions, nil
+}
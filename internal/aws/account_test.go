@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/account"
+	"github.com/aws/aws-sdk-go-v2/service/account/types"
+)
+
+// fakeAccountAPI is an in-memory accountAPI - no AWS calls happen in these tests. It returns
+// the regions of the account named by AccountId, or of self if there's none.
+type fakeAccountAPI struct {
+	self    string
+	regions map[string][]string
+	err     error
+
+	mu       sync.Mutex
+	accounts []string
+}
+
+func (f *fakeAccountAPI) ListRegions(ctx context.Context, params *account.ListRegionsInput, optFns ...func(*account.Options)) (*account.ListRegionsOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if !slices.Contains(params.RegionOptStatusContains, types.RegionOptStatusEnabledByDefault) {
+		return nil, errors.New("expected the regions enabled by default to be listed")
+	}
+
+	id := f.self
+	if params.AccountId != nil {
+		id = *params.AccountId
+	}
+	f.mu.Lock()
+	f.accounts = append(f.accounts, id)
+	f.mu.Unlock()
+
+	var out account.ListRegionsOutput
+	for _, name := range f.regions[id] {
+		out.Regions = append(out.Regions, types.Region{RegionName: strPtr(name), RegionOptStatus: types.RegionOptStatusEnabled})
+	}
+	return &out, nil
+}
+
+func TestAccountClient_ListEnabledRegions_Organization(t *testing.T) {
+	api := &fakeAccountAPI{
+		self: "999999999999",
+		regions: map[string][]string{
+			"111111111111": {"eu-west-1", "us-east-1"},
+			"999999999999": {"us-east-1"},
+		},
+	}
+	c := &accountClient{
+		client:          api,
+		callerAccountID: func(context.Context) (string, error) { return "999999999999", nil },
+	}
+
+	got, err := c.ListEnabledRegions(t.Context(), []RegionsTarget{{AccountID: "111111111111"}, {AccountID: "999999999999"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"111111111111": {"eu-west-1", "us-east-1"},
+		"999999999999": {"us-east-1"},
+	}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("ListEnabledRegions() = %v, want %v", got, want)
+	}
+}
+
+func TestAccountClient_ListEnabledRegions_MemberRole(t *testing.T) {
+	members := map[string]*fakeAccountAPI{
+		"arn:aws:iam::111111111111:role/steampipe": {self: "111111111111", regions: map[string][]string{"111111111111": {"ap-east-1"}}},
+	}
+	var gotExternalID string
+	c := &accountClient{
+		callerAccountID: func(context.Context) (string, error) {
+			t.Error("the caller identity isn't needed to list a member account's regions from inside it")
+			return "", nil
+		},
+		memberClient: func(roleARN, externalID string) accountAPI {
+			gotExternalID = externalID
+			return members[roleARN]
+		},
+	}
+
+	got, err := c.ListEnabledRegions(t.Context(), []RegionsTarget{
+		{AccountID: "111111111111", RoleARN: "arn:aws:iam::111111111111:role/steampipe", ExternalID: "tenant-42"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"ap-east-1"}; !slices.Equal(got["111111111111"], want) {
+		t.Errorf("regions = %v, want %v", got["111111111111"], want)
+	}
+	if gotExternalID != "tenant-42" {
+		t.Errorf("external ID = %q, want %q", gotExternalID, "tenant-42")
+	}
+}
+
+func TestAccountClient_ListEnabledRegions_Error(t *testing.T) {
+	boom := errors.New("boom")
+	c := &accountClient{
+		client:          &fakeAccountAPI{err: boom},
+		callerAccountID: func(context.Context) (string, error) { return "999999999999", nil },
+	}
+
+	_, err := c.ListEnabledRegions(t.Context(), []RegionsTarget{{AccountID: "111111111111"}})
+	if !errors.Is(err, boom) {
+		t.Errorf("error = %v, want it to wrap %v", err, boom)
+	}
+}
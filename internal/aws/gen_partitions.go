@@ -0,0 +1,98 @@
+//go:build ignore
+
+// gen_partitions writes partitions_gen.go from the partition metadata of the AWS SDK version
+// go.mod requires: aws-sdk-go-v2's internal/endpoints/awsrulesfn/partitions.json, which isn't
+// importable. Run go generate after upgrading the SDK to pick up new regions.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	sdkModule    = "github.com/aws/aws-sdk-go-v2"
+	metadataFile = "internal/endpoints/awsrulesfn/partitions.json"
+	outputFile   = "partitions_gen.go"
+)
+
+// metadata is the subset of partitions.json this generator reads.
+type metadata struct {
+	Partitions []struct {
+		ID          string                     `json:"id"`
+		RegionRegex string                     `json:"regionRegex"`
+		Regions     map[string]json.RawMessage `json:"regions"`
+	} `json:"partitions"`
+}
+
+func main() {
+	if err := generate(); err != nil {
+		fmt.Fprintln(os.Stderr, "gen_partitions:", err)
+		os.Exit(1)
+	}
+}
+
+func generate() error {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Version}} {{.Dir}}", sdkModule).Output()
+	if err != nil {
+		return fmt.Errorf("locating %s: %w", sdkModule, err)
+	}
+	version, dir, ok := strings.Cut(strings.TrimSpace(string(out)), " ")
+	if !ok || dir == "" {
+		return fmt.Errorf("locating %s: module not downloaded, run go mod download", sdkModule)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, metadataFile))
+	if err != nil {
+		return err
+	}
+	var m metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("parsing %s: %w", metadataFile, err)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by gen_partitions.go from %s %s; DO NOT EDIT.\n\n", sdkModule, version)
+	src.WriteString("package aws\n\nimport \"regexp\"\n\n")
+	src.WriteString("// sdkPartitions is the AWS SDK's partition metadata.\n")
+	src.WriteString("var sdkPartitions = []partitionMetadata{\n")
+	for _, p := range m.Partitions {
+		re, err := regexp.Compile(p.RegionRegex)
+		if err != nil {
+			return fmt.Errorf("partition %s: region regex: %w", p.ID, err)
+		}
+		fmt.Fprintf(&src, "{\nid: %q,\nregionRegex: regexp.MustCompile(%s),\nregions: []string{\n", p.ID, goString(p.RegionRegex))
+		for _, region := range slices.Sorted(maps.Keys(p.Regions)) {
+			// Leave out pseudo-regions such as "aws-global", which aren't region names.
+			if re.MatchString(region) {
+				fmt.Fprintf(&src, "%q,\n", region)
+			}
+		}
+		src.WriteString("},\n},\n")
+	}
+	src.WriteString("}\n")
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w", outputFile, err)
+	}
+	return os.WriteFile(outputFile, formatted, 0o644)
+}
+
+// goString returns s as a Go string literal: a raw one if possible, for readable regexes.
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
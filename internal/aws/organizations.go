@@ -3,6 +3,7 @@ package aws
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	awssdk "github.com/aws/aws-sdk-go-v2/aws"
@@ -13,7 +14,8 @@ import (
 )
 
 // maxConcurrentTagFetches and maxConcurrentOUFetches bound the number of concurrent
-// per-account calls to stay under AWS Organizations' rate limits.
+// per-account (tags) and per-parent (organization tree) calls to stay under AWS
+// Organizations' rate limits.
 const (
 	maxConcurrentTagFetches = 8 // under 10 TPS, burst 15 limit
 	maxConcurrentOUFetches  = 3 // under 5 TPS, burst 8 limit
@@ -23,7 +25,9 @@ const (
 type organizationsAPI interface {
 	organizations.ListAccountsAPIClient
 	organizations.ListTagsForResourceAPIClient
-	ListParents(ctx context.Context, params *organizations.ListParentsInput, optFns ...func(*organizations.Options)) (*organizations.ListParentsOutput, error)
+	organizations.ListRootsAPIClient
+	organizations.ListOrganizationalUnitsForParentAPIClient
+	organizations.ListAccountsForParentAPIClient
 }
 
 type organizationsClient struct {
@@ -31,34 +35,44 @@ type organizationsClient struct {
 }
 
 // NewOrganizationsClient returns a client backed by the real AWS SDK, using an aggressive
-// retry policy since AWS Organizations has strict rate limits. Its ListAccounts method
-// satisfies generator.OrganizationsClient.
+// retry policy since AWS Organizations has strict rate limits. It satisfies
+// generator.OrganizationsClient.
 func NewOrganizationsClient(cfg awssdk.Config) *organizationsClient {
-	cfg.Retryer = func() awssdk.Retryer {
-		return retry.NewStandard(func(o *retry.StandardOptions) {
-			o.MaxAttempts = 5
-			o.Backoff = retry.NewExponentialJitterBackoff(time.Second)
-		})
-	}
-
+	cfg.Retryer = throttledRetryer
 	return &organizationsClient{client: organizations.NewFromConfig(cfg)}
 }
 
-func (c *organizationsClient) ListAccounts(ctx context.Context) ([]Account, error) {
+// throttledRetryer retries more, and backs off longer, than the SDK's default retryer, for
+// APIs with strict rate limits.
+func throttledRetryer() awssdk.Retryer {
+	return retry.NewStandard(func(o *retry.StandardOptions) {
+		o.MaxAttempts = 5
+		o.Backoff = retry.NewExponentialJitterBackoff(time.Second)
+	})
+}
+
+// ListAccounts returns all ACTIVE accounts in the organization, with tags, OU and OUPath
+// populated, along with every node of the organization tree: its root(s) and all organizational
+// units nested below them, in breadth-first order. Both come from a single walk of the tree.
+func (c *organizationsClient) ListAccounts(ctx context.Context) ([]Account, []OrganizationalUnit, error) {
 	accounts, err := c.listActiveAccounts(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("listing accounts: %w", err)
+		return nil, nil, fmt.Errorf("listing accounts: %w", err)
 	}
 
+	var units []OrganizationalUnit
 	group, ctx := errgroup.WithContext(ctx)
 	group.Go(func() error { return c.fetchTags(ctx, accounts) })
-	group.Go(func() error { return c.fetchOUs(ctx, accounts) })
+	group.Go(func() (err error) {
+		units, err = c.fetchOUPaths(ctx, accounts)
+		return err
+	})
 
 	if err := group.Wait(); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return accounts, nil
+	return accounts, units, nil
 }
 
 func (c *organizationsClient) listActiveAccounts(ctx context.Context) ([]Account, error) {
@@ -75,7 +89,14 @@ func (c *organizationsClient) listActiveAccounts(ctx context.Context) ([]Account
 			if acc.State != types.AccountStateActive {
 				continue
 			}
-			accounts = append(accounts, Account{ID: *acc.Id, Name: *acc.Name})
+			accounts = append(accounts, Account{
+				ID:              awssdk.ToString(acc.Id),
+				Name:            awssdk.ToString(acc.Name),
+				Email:           awssdk.ToString(acc.Email),
+				ARN:             awssdk.ToString(acc.Arn),
+				JoinedTimestamp: awssdk.ToTime(acc.JoinedTimestamp),
+				JoinedMethod:    string(acc.JoinedMethod),
+			})
 		}
 	}
 
@@ -96,17 +117,144 @@ func (c *organizationsClient) fetchTags(ctx context.Context, accounts []Account)
 	})
 }
 
-// fetchOUs fills in the OU field of each account, bounded by maxConcurrentOUFetches. The first
-// real error cancels the fetch and is returned.
-func (c *organizationsClient) fetchOUs(ctx context.Context, accounts []Account) error {
-	return fetchConcurrently(ctx, len(accounts), maxConcurrentOUFetches, func(ctx context.Context, i int) error {
-		ou, err := c.getAccountOU(ctx, accounts[i].ID)
+// fetchOUPaths fills in the OU and OUPath fields of each account from a single walk of the
+// organization tree (see walkOrganization), rather than one ListParents call per account and
+// per ancestor. It returns the units the walk found.
+func (c *organizationsClient) fetchOUPaths(ctx context.Context, accounts []Account) ([]OrganizationalUnit, error) {
+	tree, err := c.walkOrganization(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("walking organization tree: %w", err)
+	}
+
+	for i := range accounts {
+		path, ok := tree.accountPaths[accounts[i].ID]
+		if !ok {
+			return nil, fmt.Errorf("account %s: not found in the organization tree", accounts[i].ID)
+		}
+		accounts[i].OU = path[len(path)-1].ID
+		accounts[i].OUPath = path
+	}
+	return tree.units, nil
+}
+
+// organizationTree is the result of walkOrganization.
+type organizationTree struct {
+	// units lists the root(s) and every organizational unit, in breadth-first order.
+	units []OrganizationalUnit
+	// accountPaths maps each account ID to its OU path.
+	accountPaths map[string][]OrganizationalUnit
+}
+
+// walkOrganization walks the organization tree breadth-first from its root, listing each
+// parent's child OUs and child accounts. Every parent on a level is listed
+// concurrently, bounded by maxConcurrentOUFetches; the first real error cancels the walk and
+// is returned.
+func (c *organizationsClient) walkOrganization(ctx context.Context) (organizationTree, error) {
+	roots, err := c.listRoots(ctx)
+	if err != nil {
+		return organizationTree{}, err
+	}
+
+	// level holds the path to each parent still to be listed, ending with the parent itself.
+	level := make([][]OrganizationalUnit, 0, len(roots))
+	for _, root := range roots {
+		level = append(level, []OrganizationalUnit{root})
+	}
+
+	tree := organizationTree{accountPaths: make(map[string][]OrganizationalUnit)}
+	for len(level) > 0 {
+		childOUs := make([][]OrganizationalUnit, len(level))
+		childAccounts := make([][]string, len(level))
+
+		err := fetchConcurrently(ctx, len(level), maxConcurrentOUFetches, func(ctx context.Context, i int) error {
+			parentID := level[i][len(level[i])-1].ID
+
+			ous, err := c.listChildOUs(ctx, parentID)
+			if err != nil {
+				return fmt.Errorf("parent %s: %w", parentID, err)
+			}
+			childOUs[i] = ous
+
+			accountIDs, err := c.listChildAccounts(ctx, parentID)
+			if err != nil {
+				return fmt.Errorf("parent %s: %w", parentID, err)
+			}
+			childAccounts[i] = accountIDs
+			return nil
+		})
 		if err != nil {
-			return fmt.Errorf("account %s: fetching OU: %w", accounts[i].ID, err)
+			return organizationTree{}, err
 		}
-		accounts[i].OU = ou
-		return nil
+
+		var next [][]OrganizationalUnit
+		for i, path := range level {
+			tree.units = append(tree.units, path[len(path)-1])
+			for _, id := range childAccounts[i] {
+				tree.accountPaths[id] = path
+			}
+			for _, ou := range childOUs[i] {
+				next = append(next, slices.Concat(path, []OrganizationalUnit{ou}))
+			}
+		}
+		level = next
+	}
+
+	return tree, nil
+}
+
+func (c *organizationsClient) listRoots(ctx context.Context) ([]OrganizationalUnit, error) {
+	var roots []OrganizationalUnit
+
+	paginator := organizations.NewListRootsPaginator(c.client, &organizations.ListRootsInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing roots: %w", err)
+		}
+		for _, root := range page.Roots {
+			roots = append(roots, OrganizationalUnit{ID: *root.Id, Name: *root.Name})
+		}
+	}
+
+	return roots, nil
+}
+
+func (c *organizationsClient) listChildOUs(ctx context.Context, parentID string) ([]OrganizationalUnit, error) {
+	var ous []OrganizationalUnit
+
+	paginator := organizations.NewListOrganizationalUnitsForParentPaginator(c.client, &organizations.ListOrganizationalUnitsForParentInput{
+		ParentId: &parentID,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing organizational units: %w", err)
+		}
+		for _, ou := range page.OrganizationalUnits {
+			ous = append(ous, OrganizationalUnit{ID: *ou.Id, Name: *ou.Name})
+		}
+	}
+
+	return ous, nil
+}
+
+func (c *organizationsClient) listChildAccounts(ctx context.Context, parentID string) ([]string, error) {
+	var ids []string
+
+	paginator := organizations.NewListAccountsForParentPaginator(c.client, &organizations.ListAccountsForParentInput{
+		ParentId: &parentID,
 	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing accounts for parent: %w", err)
+		}
+		for _, acc := range page.Accounts {
+			ids = append(ids, *acc.Id)
+		}
+	}
+
+	return ids, nil
 }
 
 func (c *organizationsClient) listAccountTags(ctx context.Context, accountID string) (map[string]string, error) {
@@ -127,14 +275,3 @@ func (c *organizationsClient) listAccountTags(ctx context.Context, accountID str
 
 	return tags, nil
 }
-
-func (c *organizationsClient) getAccountOU(ctx context.Context, accountID string) (string, error) {
-	resp, err := c.client.ListParents(ctx, &organizations.ListParentsInput{ChildId: &accountID})
-	if err != nil {
-		return "", fmt.Errorf("listing parents: %w", err)
-	}
-	if len(resp.Parents) == 0 {
-		return "", fmt.Errorf("no parent OU found for account %s", accountID)
-	}
-	return *resp.Parents[0].Id, nil
-}
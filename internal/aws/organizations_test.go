@@ -3,7 +3,9 @@ package aws
 import (
 	"context"
 	"errors"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	"github.com/aws/aws-sdk-go-v2/service/organizations/types"
@@ -19,8 +21,12 @@ type fakeOrganizationsAPI struct {
 	tags    map[string][]types.Tag
 	tagsErr map[string]error
 
-	parents    map[string][]types.Parent
-	parentsErr map[string]error
+	// roots, ous and children describe the organization tree: ous and children map a parent
+	// ID to its child OUs and child accounts respectively.
+	roots    []types.Root
+	ous      map[string][]types.OrganizationalUnit
+	children map[string][]types.Account
+	treeErr  map[string]error
 }
 
 func (f *fakeOrganizationsAPI) ListAccounts(ctx context.Context, params *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error) {
@@ -38,20 +44,49 @@ func (f *fakeOrganizationsAPI) ListTagsForResource(ctx context.Context, params *
 	return &organizations.ListTagsForResourceOutput{Tags: f.tags[id]}, nil
 }
 
-func (f *fakeOrganizationsAPI) ListParents(ctx context.Context, params *organizations.ListParentsInput, optFns ...func(*organizations.Options)) (*organizations.ListParentsOutput, error) {
-	id := *params.ChildId
-	if err := f.parentsErr[id]; err != nil {
+func (f *fakeOrganizationsAPI) ListRoots(ctx context.Context, params *organizations.ListRootsInput, optFns ...func(*organizations.Options)) (*organizations.ListRootsOutput, error) {
+	return &organizations.ListRootsOutput{Roots: f.roots}, nil
+}
+
+func (f *fakeOrganizationsAPI) ListOrganizationalUnitsForParent(ctx context.Context, params *organizations.ListOrganizationalUnitsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListOrganizationalUnitsForParentOutput, error) {
+	id := *params.ParentId
+	if err := f.treeErr[id]; err != nil {
+		return nil, err
+	}
+	return &organizations.ListOrganizationalUnitsForParentOutput{OrganizationalUnits: f.ous[id]}, nil
+}
+
+func (f *fakeOrganizationsAPI) ListAccountsForParent(ctx context.Context, params *organizations.ListAccountsForParentInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsForParentOutput, error) {
+	id := *params.ParentId
+	if err := f.treeErr[id]; err != nil {
 		return nil, err
 	}
-	return &organizations.ListParentsOutput{Parents: f.parents[id]}, nil
+	return &organizations.ListAccountsForParentOutput{Accounts: f.children[id]}, nil
 }
 
 func strPtr(s string) *string { return &s }
 
+// singleRoot returns a tree with every account placed directly under one root, r-root.
+func singleRoot(ids ...string) ([]types.Root, map[string][]types.Account) {
+	children := make([]types.Account, 0, len(ids))
+	for _, id := range ids {
+		children = append(children, types.Account{Id: strPtr(id)})
+	}
+	return []types.Root{{Id: strPtr("r-root"), Name: strPtr("Root")}}, map[string][]types.Account{"r-root": children}
+}
+
 func TestOrganizationsClient_ListAccounts(t *testing.T) {
 	api := &fakeOrganizationsAPI{
 		accounts: []types.Account{
-			{Id: strPtr("111111111111"), Name: strPtr("Team Foo"), State: types.AccountStateActive},
+			{
+				Id:              strPtr("111111111111"),
+				Name:            strPtr("Team Foo"),
+				Email:           strPtr("foo@example.com"),
+				Arn:             strPtr("arn:aws:organizations::999999999999:account/o-example/111111111111"),
+				JoinedTimestamp: timePtr(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+				JoinedMethod:    types.AccountJoinedMethodCreated,
+				State:           types.AccountStateActive,
+			},
 			{Id: strPtr("222222222222"), Name: strPtr("Team Bar"), State: types.AccountStateSuspended},
 			{Id: strPtr("333333333333"), Name: strPtr("Team Baz"), State: types.AccountStateActive},
 		},
@@ -59,18 +94,28 @@ func TestOrganizationsClient_ListAccounts(t *testing.T) {
 			"111111111111": {{Key: strPtr("team"), Value: strPtr("foo")}},
 			"333333333333": {{Key: strPtr("team"), Value: strPtr("baz")}},
 		},
-		parents: map[string][]types.Parent{
-			"111111111111": {{Id: strPtr("ou-root")}},
-			"333333333333": {{Id: strPtr("ou-sandbox")}},
+		roots: []types.Root{{Id: strPtr("r-root"), Name: strPtr("Root")}},
+		ous: map[string][]types.OrganizationalUnit{
+			"r-root":       {{Id: strPtr("ou-workloads"), Name: strPtr("Workloads")}},
+			"ou-workloads": {{Id: strPtr("ou-sandbox"), Name: strPtr("Sandbox")}},
+		},
+		children: map[string][]types.Account{
+			"r-root":     {{Id: strPtr("111111111111")}, {Id: strPtr("222222222222")}},
+			"ou-sandbox": {{Id: strPtr("333333333333")}},
 		},
 	}
 	c := &organizationsClient{client: api}
 
-	accounts, err := c.ListAccounts(t.Context())
+	accounts, units, err := c.ListAccounts(t.Context())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
+	wantUnits := []OrganizationalUnit{{ID: "r-root", Name: "Root"}, {ID: "ou-workloads", Name: "Workloads"}, {ID: "ou-sandbox", Name: "Sandbox"}}
+	if !slices.Equal(units, wantUnits) {
+		t.Errorf("units = %v, want %v", units, wantUnits)
+	}
+
 	if len(accounts) != 2 {
 		t.Fatalf("got %d accounts, want 2 (only ACTIVE): %+v", len(accounts), accounts)
 	}
@@ -87,8 +132,20 @@ func TestOrganizationsClient_ListAccounts(t *testing.T) {
 	if foo.Name != "Team Foo" {
 		t.Errorf("Name = %q, want %q", foo.Name, "Team Foo")
 	}
-	if foo.OU != "ou-root" {
-		t.Errorf("OU = %q, want %q", foo.OU, "ou-root")
+	if foo.OU != "r-root" {
+		t.Errorf("OU = %q, want %q", foo.OU, "r-root")
+	}
+	if foo.Email != "foo@example.com" {
+		t.Errorf("Email = %q, want %q", foo.Email, "foo@example.com")
+	}
+	if foo.ARN != "arn:aws:organizations::999999999999:account/o-example/111111111111" {
+		t.Errorf("ARN = %q", foo.ARN)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !foo.JoinedTimestamp.Equal(want) {
+		t.Errorf("JoinedTimestamp = %v, want %v", foo.JoinedTimestamp, want)
+	}
+	if foo.JoinedMethod != "CREATED" {
+		t.Errorf("JoinedMethod = %q, want %q", foo.JoinedMethod, "CREATED")
 	}
 	if foo.Tags["team"] != "foo" {
 		t.Errorf(`Tags["team"] = %q, want %q`, foo.Tags["team"], "foo")
@@ -97,13 +154,22 @@ func TestOrganizationsClient_ListAccounts(t *testing.T) {
 	if _, ok := byID["222222222222"]; ok {
 		t.Error("suspended account 222222222222 should have been excluded")
 	}
+
+	baz := byID["333333333333"]
+	if baz.OU != "ou-sandbox" {
+		t.Errorf("OU = %q, want %q (direct parent)", baz.OU, "ou-sandbox")
+	}
+	wantPath := []OrganizationalUnit{{ID: "r-root", Name: "Root"}, {ID: "ou-workloads", Name: "Workloads"}, {ID: "ou-sandbox", Name: "Sandbox"}}
+	if !slices.Equal(baz.OUPath, wantPath) {
+		t.Errorf("OUPath = %v, want %v", baz.OUPath, wantPath)
+	}
 }
 
 func TestOrganizationsClient_ListAccounts_ListAccountsError(t *testing.T) {
 	wantErr := errors.New("TooManyRequestsException")
 	c := &organizationsClient{client: &fakeOrganizationsAPI{accountsErr: wantErr}}
 
-	_, err := c.ListAccounts(t.Context())
+	_, _, err := c.ListAccounts(t.Context())
 	if !errors.Is(err, wantErr) {
 		t.Errorf("error = %v, want it to wrap %v", err, wantErr)
 	}
@@ -115,13 +181,12 @@ func TestOrganizationsClient_ListAccounts_TagsErrorIsNotSilenced(t *testing.T) {
 		accounts: []types.Account{
 			{Id: strPtr("111111111111"), Name: strPtr("Team Foo"), State: types.AccountStateActive},
 		},
-		tagsErr:    map[string]error{"111111111111": wantErr},
-		parents:    map[string][]types.Parent{"111111111111": {{Id: strPtr("ou-root")}}},
-		parentsErr: map[string]error{},
+		tagsErr: map[string]error{"111111111111": wantErr},
 	}
+	api.roots, api.children = singleRoot("111111111111")
 	c := &organizationsClient{client: api}
 
-	_, err := c.ListAccounts(t.Context())
+	_, _, err := c.ListAccounts(t.Context())
 	if !errors.Is(err, wantErr) {
 		t.Errorf("error = %v, want it to wrap %v", err, wantErr)
 	}
@@ -133,23 +198,69 @@ func TestOrganizationsClient_ListAccounts_OUErrorIsNotSilenced(t *testing.T) {
 		accounts: []types.Account{
 			{Id: strPtr("111111111111"), Name: strPtr("Team Foo"), State: types.AccountStateActive},
 		},
-		tags:       map[string][]types.Tag{"111111111111": nil},
-		parentsErr: map[string]error{"111111111111": wantErr},
+		tags:  map[string][]types.Tag{"111111111111": nil},
+		roots: []types.Root{{Id: strPtr("r-root"), Name: strPtr("Root")}},
+		ous: map[string][]types.OrganizationalUnit{
+			"r-root": {{Id: strPtr("ou-workloads"), Name: strPtr("Workloads")}},
+		},
+		treeErr: map[string]error{"ou-workloads": wantErr},
 	}
 	c := &organizationsClient{client: api}
 
-	_, err := c.ListAccounts(t.Context())
+	_, _, err := c.ListAccounts(t.Context())
 	if !errors.Is(err, wantErr) {
 		t.Errorf("error = %v, want it to wrap %v", err, wantErr)
 	}
 }
 
-func TestOrganizationsClient_GetAccountOU_NoParent(t *testing.T) {
-	api := &fakeOrganizationsAPI{parents: map[string][]types.Parent{}}
+// An account that ListAccounts returns but the tree walk never reaches (e.g. one moved between
+// the two calls) must fail loudly rather than silently get an empty OU path that no --skipOUs
+// entry can ever match.
+func TestOrganizationsClient_FetchOUPaths_AccountNotInTree(t *testing.T) {
+	api := &fakeOrganizationsAPI{}
+	api.roots, api.children = singleRoot("222222222222")
 	c := &organizationsClient{client: api}
 
-	_, err := c.getAccountOU(t.Context(), "111111111111")
+	_, err := c.fetchOUPaths(t.Context(), []Account{{ID: "111111111111"}})
 	if err == nil {
-		t.Fatal("expected an error when an account has no parent OU")
+		t.Fatal("expected an error when an account isn't found in the organization tree")
+	}
+}
+
+func TestOrganizationsClient_WalkOrganization_NestedOUs(t *testing.T) {
+	api := &fakeOrganizationsAPI{
+		roots: []types.Root{{Id: strPtr("r-root"), Name: strPtr("Root")}},
+		ous: map[string][]types.OrganizationalUnit{
+			"r-root":       {{Id: strPtr("ou-workloads"), Name: strPtr("Workloads")}, {Id: strPtr("ou-security"), Name: strPtr("Security")}},
+			"ou-workloads": {{Id: strPtr("ou-prod"), Name: strPtr("Prod")}},
+			"ou-prod":      {{Id: strPtr("ou-eu"), Name: strPtr("EU")}},
+		},
+		children: map[string][]types.Account{
+			"ou-security": {{Id: strPtr("111111111111")}},
+			"ou-eu":       {{Id: strPtr("222222222222")}},
+		},
+	}
+	c := &organizationsClient{client: api}
+
+	tree, err := c.walkOrganization(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ids := func(path []OrganizationalUnit) []string {
+		out := make([]string, 0, len(path))
+		for _, ou := range path {
+			out = append(out, ou.ID)
+		}
+		return out
+	}
+	if got, want := ids(tree.accountPaths["111111111111"]), []string{"r-root", "ou-security"}; !slices.Equal(got, want) {
+		t.Errorf("path of 111111111111 = %v, want %v", got, want)
+	}
+	if got, want := ids(tree.accountPaths["222222222222"]), []string{"r-root", "ou-workloads", "ou-prod", "ou-eu"}; !slices.Equal(got, want) {
+		t.Errorf("path of 222222222222 = %v, want %v", got, want)
+	}
+	if got, want := ids(tree.units), []string{"r-root", "ou-workloads", "ou-security", "ou-prod", "ou-eu"}; !slices.Equal(got, want) {
+		t.Errorf("units = %v, want %v", got, want)
 	}
 }
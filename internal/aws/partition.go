@@ -0,0 +1,52 @@
+package aws
+
+import (
+	"slices"
+	"strings"
+)
+
+// The most common AWS partitions: groups of regions with their own ARN namespace. Credentials,
+// and so roles, can't cross from one partition to another. Partitions lists them all.
+const (
+	PartitionAWS      = "aws"
+	PartitionAWSUSGov = "aws-us-gov"
+	PartitionAWSCN    = "aws-cn"
+)
+
+// Partitions lists every partition in the AWS SDK's partition metadata (see sdkPartitions).
+var Partitions = partitionIDs()
+
+func partitionIDs() []string {
+	ids := make([]string, 0, len(sdkPartitions))
+	for _, p := range sdkPartitions {
+		ids = append(ids, p.id)
+	}
+	return ids
+}
+
+// PartitionForRegion returns the partition region is in: the one listing it, or else the one
+// whose region names it looks like, e.g. PartitionAWSUSGov for "us-gov-west-1" or "aws-iso" for
+// "us-iso-east-1". It's PartitionAWS if none does.
+func PartitionForRegion(region string) string {
+	for _, p := range sdkPartitions {
+		if slices.Contains(p.regions, region) {
+			return p.id
+		}
+	}
+	for _, p := range sdkPartitions {
+		if p.regionRegex.MatchString(region) {
+			return p.id
+		}
+	}
+	return PartitionAWS
+}
+
+// ARNPartition returns the partition of arn, and whether arn is an ARN in a supported
+// partition.
+func ARNPartition(arn string) (string, bool) {
+	fields := strings.SplitN(arn, ":", 3)
+	if len(fields) < 3 || fields[0] != "arn" || !slices.Contains(Partitions, fields[1]) {
+		return "", false
+	}
+	return fields[1], true
+}
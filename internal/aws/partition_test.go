@@ -0,0 +1,47 @@
+package aws
+
+import "testing"
+
+func TestPartitionForRegion(t *testing.T) {
+	tests := map[string]string{
+		"us-east-1":      PartitionAWS,
+		"eu-west-1":      PartitionAWS,
+		"":               PartitionAWS,
+		"us-gov-west-1":  PartitionAWSUSGov,
+		"us-gov-east-1":  PartitionAWSUSGov,
+		"cn-north-1":     PartitionAWSCN,
+		"cn-northwest-1": PartitionAWSCN,
+		"us-iso-east-1":  "aws-iso",
+		"eusc-de-east-1": "aws-eusc",
+		"us-gov-north-1": PartitionAWSUSGov,
+	}
+	for region, want := range tests {
+		if got := PartitionForRegion(region); got != want {
+			t.Errorf("PartitionForRegion(%q) = %q, want %q", region, got, want)
+		}
+	}
+}
+
+func TestARNPartition(t *testing.T) {
+	tests := []struct {
+		arn    string
+		want   string
+		wantOK bool
+	}{
+		{"arn:aws:organizations::999999999999:account/o-example/111111111111", PartitionAWS, true},
+		{"arn:aws-us-gov:organizations::999999999999:account/o-example/111111111111", PartitionAWSUSGov, true},
+		{"arn:aws-cn:iam::111111111111:role/my-role", PartitionAWSCN, true},
+		{"arn:aws-iso:iam::111111111111:role/my-role", "aws-iso", true},
+		{"arn:aws-eusc:iam::111111111111:role/my-role", "aws-eusc", true},
+		{"arn:aws-moon:iam::111111111111:role/my-role", "", false},
+		{"aws:iam::111111111111:role/my-role", "", false},
+		{"arn:aws", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := ARNPartition(tt.arn)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ARNPartition(%q) = %q, %v, want %q, %v", tt.arn, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+//go:generate go run gen_partitions.go
+
+// partitionMetadata describes an AWS partition, as listed in sdkPartitions.
+type partitionMetadata struct {
+	id string
+	// regionRegex matches the name of every region of the partition, including those launched
+	// after the SDK sdkPartitions was generated from.
+	regionRegex *regexp.Regexp
+	// regions lists the regions of the partition known to that SDK, sorted.
+	regions []string
+}
+
+// lookupPartition returns the metadata of partition, the zero value if it's unknown.
+func lookupPartition(partition string) partitionMetadata {
+	for _, p := range sdkPartitions {
+		if p.id == partition {
+			return p
+		}
+	}
+	return partitionMetadata{}
+}
+
+// ErrUnknownRegion is wrapped by the error ValidateRegion and MatchRegions return for a region
+// that's well-formed for its partition without being one of its known regions, nor a near-miss
+// of one: most likely a region launched after this build, which callers should warn about
+// rather than reject.
+var ErrUnknownRegion = errors.New("unknown region")
+
+// regionPatternChars matches a region name, or a region pattern where "*" and "?" are
+// wildcards.
+var regionPatternChars = regexp.MustCompile(`^[a-z0-9*?-]+$`)
+
+// Regions returns the known regions of partition.
+func Regions(partition string) []string {
+	return slices.Clone(lookupPartition(partition).regions)
+}
+
+// ValidateRegion checks that region is one of the known regions of partition, suggesting the
+// one it was most likely meant to be if it's a near-miss of one. A region that's well-formed
+// for partition otherwise gets an error wrapping ErrUnknownRegion.
+func ValidateRegion(partition, region string) error {
+	p := lookupPartition(partition)
+	if slices.Contains(p.regions, region) {
+		return nil
+	}
+	for _, other := range sdkPartitions {
+		if slices.Contains(other.regions, region) {
+			return fmt.Errorf("region %q is in partition %q, not %q", region, other.id, partition)
+		}
+	}
+
+	// A well-formed region is more likely a new one, such as "ap-southeast-8" or "me-north-2",
+	// than a typo, unless it's a single edit away from a known region of another area, such as
+	// "eu-wset-1".
+	wellFormed := p.regionRegex != nil && p.regionRegex.MatchString(region)
+	maxDistance := len(region) / 3
+	if wellFormed {
+		maxDistance = 1
+	}
+	suggestion := closestRegion(p.regions, region, maxDistance)
+	switch {
+	case suggestion != "" && (!wellFormed || regionArea(suggestion) != regionArea(region)):
+		return fmt.Errorf("unknown region %q in partition %q, did you mean %q?", region, partition, suggestion)
+	case wellFormed:
+		return fmt.Errorf("%w %q in partition %q: not one of the regions this build knows, using it as is", ErrUnknownRegion, region, partition)
+	default:
+		return fmt.Errorf("unknown region %q in partition %q", region, partition)
+	}
+}
+
+// MatchRegions returns the known regions of partition matching pattern, where "*" matches any
+// run of characters and "?" any one, sorted. A pattern matching none is an error, suggesting
+// what it was most likely meant to be if it's a region name. A region name is checked with
+// ValidateRegion: if the error wraps ErrUnknownRegion, the name is still returned along with it.
+func MatchRegions(partition, pattern string) ([]string, error) {
+	if !regionPatternChars.MatchString(pattern) {
+		return nil, fmt.Errorf("region %q must only contain lowercase letters, digits, dashes and the wildcards * and ?", pattern)
+	}
+	if !strings.ContainsAny(pattern, "*?") {
+		err := ValidateRegion(partition, pattern)
+		if err != nil && !errors.Is(err, ErrUnknownRegion) {
+			return nil, err
+		}
+		return []string{pattern}, err
+	}
+
+	var matches []string
+	for _, region := range lookupPartition(partition).regions {
+		// pattern only has characters path.Match reads literally, or as wildcards.
+		if ok, _ := path.Match(pattern, region); ok {
+			matches = append(matches, region)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("region pattern %q matches no region in partition %q", pattern, partition)
+	}
+	slices.Sort(matches)
+	return matches, nil
+}
+
+// ParseRegions parses a list of regions, as given to --regions or by a regions override tag,
+// checking every region name and pattern against the regions of partition: "all" alone, or
+// regions and patterns with "*" and "?" wildcards, e.g. "eu-*" and "us-east-1", kept as is for
+// Steampipe to expand. Entries prefixed with "!" exclude the regions they match, e.g. "*" and
+// "!ap-east-1": the list is then expanded into the regions of partition, since Steampipe has no
+// exclusion syntax, and only exclusions means every region but those. Regions unknown to this
+// build (see ErrUnknownRegion) are kept, and also returned in unknown.
+func ParseRegions(partition string, entries []string) (regions, unknown []string, err error) {
+	if slices.Equal(entries, []string{"all"}) {
+		return []string{"*"}, nil, nil
+	}
+
+	var include, exclude []string
+	for _, entry := range entries {
+		if pattern, ok := strings.CutPrefix(entry, "!"); ok {
+			exclude = append(exclude, pattern)
+		} else {
+			include = append(include, entry)
+		}
+	}
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil, fmt.Errorf("must list regions, or be all")
+	}
+
+	match := func(pattern string) ([]string, error) {
+		matches, err := MatchRegions(partition, pattern)
+		if errors.Is(err, ErrUnknownRegion) {
+			unknown = append(unknown, pattern)
+			return matches, nil
+		}
+		return matches, err
+	}
+
+	var included []string
+	for _, pattern := range include {
+		matches, err := match(pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		included = append(included, matches...)
+	}
+	if len(exclude) == 0 {
+		return include, unknown, nil
+	}
+	if len(include) == 0 {
+		included = Regions(partition)
+	}
+
+	var excluded []string
+	for _, pattern := range exclude {
+		matches, err := match(pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		excluded = append(excluded, matches...)
+	}
+
+	regions = slices.DeleteFunc(included, func(region string) bool {
+		return slices.Contains(excluded, region)
+	})
+	if len(regions) == 0 {
+		return nil, nil, fmt.Errorf("%q excludes every region", strings.Join(entries, " "))
+	}
+	slices.Sort(regions)
+	return slices.Compact(regions), unknown, nil
+}
+
+// regionArea returns region without its trailing number, e.g. "eu-west" for "eu-west-1".
+func regionArea(region string) string {
+	i := strings.LastIndexByte(region, '-')
+	if i < 0 {
+		return region
+	}
+	return region[:i]
+}
+
+// closestRegion returns the one of regions closest to region, if it's at most maxDistance
+// edits away.
+func closestRegion(regions []string, region string, maxDistance int) string {
+	var closest string
+	best := maxDistance + 1
+	for _, candidate := range regions {
+		if d := editDistance(region, candidate); d < best {
+			closest, best = candidate, d
+		}
+	}
+	return closest
+}
+
+// editDistance returns the Damerau-Levenshtein (optimal string alignment) distance between a
+// and b: the number of single-character insertions, deletions, substitutions and transpositions
+// of adjacent characters turning a into b, so that "eu-wset-1" is one edit from "eu-west-1".
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
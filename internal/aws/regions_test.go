@@ -0,0 +1,149 @@
+package aws
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidateRegion(t *testing.T) {
+	tests := []struct {
+		partition string
+		region    string
+		wantErr   string
+	}{
+		{PartitionAWS, "eu-west-1", ""},
+		{PartitionAWSUSGov, "us-gov-west-1", ""},
+		{PartitionAWSCN, "cn-northwest-1", ""},
+		{PartitionAWS, "eu-wset-1", `did you mean "eu-west-1"?`},
+		{PartitionAWS, "us-east1", `did you mean "us-east-1"?`},
+		{PartitionAWS, "europe", `unknown region "europe" in partition "aws"`},
+		{PartitionAWS, "us-gov-west-1", `is in partition "aws-us-gov", not "aws"`},
+		{PartitionAWSCN, "eu-west-1", `is in partition "aws", not "aws-cn"`},
+		{PartitionAWS, "", "unknown region"},
+	}
+	for _, tt := range tests {
+		err := ValidateRegion(tt.partition, tt.region)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("ValidateRegion(%q, %q) unexpected error: %v", tt.partition, tt.region, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ValidateRegion(%q, %q) error = %v, want one containing %q", tt.partition, tt.region, err, tt.wantErr)
+		}
+	}
+}
+
+// A well-formed region this build doesn't know is likely a new one, not a typo.
+func TestValidateRegion_Unknown(t *testing.T) {
+	tests := []struct {
+		partition string
+		region    string
+	}{
+		{PartitionAWS, "ap-southeast-9"},
+		{PartitionAWS, "eu-west-4"},
+		{PartitionAWS, "me-north-2"},
+		{PartitionAWSUSGov, "us-gov-north-1"},
+		{"aws-iso", "us-iso-north-1"},
+	}
+	for _, tt := range tests {
+		err := ValidateRegion(tt.partition, tt.region)
+		if !errors.Is(err, ErrUnknownRegion) {
+			t.Errorf("ValidateRegion(%q, %q) error = %v, want ErrUnknownRegion", tt.partition, tt.region, err)
+		}
+	}
+
+	for _, region := range []string{"eu-wset-1", "europe", "us-gov-west-1"} {
+		if err := ValidateRegion(PartitionAWS, region); err == nil || errors.Is(err, ErrUnknownRegion) {
+			t.Errorf("ValidateRegion(%q, %q) error = %v, want one not wrapping ErrUnknownRegion", PartitionAWS, region, err)
+		}
+	}
+}
+
+func TestMatchRegions(t *testing.T) {
+	tests := []struct {
+		partition string
+		pattern   string
+		want      []string
+	}{
+		{PartitionAWS, "eu-west-1", []string{"eu-west-1"}},
+		{PartitionAWS, "eu-west-*", []string{"eu-west-1", "eu-west-2", "eu-west-3"}},
+		{PartitionAWS, "us-*-?", []string{"us-east-1", "us-east-2", "us-west-1", "us-west-2"}},
+		{PartitionAWSUSGov, "*", []string{"us-gov-east-1", "us-gov-west-1"}},
+	}
+	for _, tt := range tests {
+		got, err := MatchRegions(tt.partition, tt.pattern)
+		if err != nil {
+			t.Errorf("MatchRegions(%q, %q) unexpected error: %v", tt.partition, tt.pattern, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("MatchRegions(%q, %q) = %v, want %v", tt.partition, tt.pattern, got, tt.want)
+		}
+	}
+
+	got, err := MatchRegions(PartitionAWS, "eu-west-4")
+	if !errors.Is(err, ErrUnknownRegion) || !slices.Equal(got, []string{"eu-west-4"}) {
+		t.Errorf("MatchRegions(%q, %q) = %v, %v, want the region and ErrUnknownRegion", PartitionAWS, "eu-west-4", got, err)
+	}
+
+	for _, pattern := range []string{"eu-wset-1", "xx-*", "EU-*", "eu-[a-z]*", "us-gov-*"} {
+		if _, err := MatchRegions(PartitionAWS, pattern); err == nil {
+			t.Errorf("MatchRegions(%q, %q): expected an error", PartitionAWS, pattern)
+		}
+	}
+}
+
+func TestParseRegions(t *testing.T) {
+	tests := []struct {
+		partition   string
+		entries     []string
+		want        []string
+		wantUnknown []string
+	}{
+		{PartitionAWS, []string{"all"}, []string{"*"}, nil},
+		{PartitionAWS, []string{"eu-*", "us-east-1"}, []string{"eu-*", "us-east-1"}, nil},
+		{PartitionAWS, []string{"eu-west-*", "!eu-west-3"}, []string{"eu-west-1", "eu-west-2"}, nil},
+		{PartitionAWSUSGov, []string{"!us-gov-east-1"}, []string{"us-gov-west-1"}, nil},
+		{PartitionAWS, []string{"eu-west-1", "eu-west-4"}, []string{"eu-west-1", "eu-west-4"}, []string{"eu-west-4"}},
+	}
+	for _, tt := range tests {
+		got, unknown, err := ParseRegions(tt.partition, tt.entries)
+		if err != nil {
+			t.Errorf("ParseRegions(%q, %q) unexpected error: %v", tt.partition, tt.entries, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) || !slices.Equal(unknown, tt.wantUnknown) {
+			t.Errorf("ParseRegions(%q, %q) = %q, %q, want %q, %q", tt.partition, tt.entries, got, unknown, tt.want, tt.wantUnknown)
+		}
+	}
+
+	for _, entries := range [][]string{nil, {"all", "eu-west-1"}, {"eu-wset-1"}, {"*", "!eu-wset-1"}, {"eu-west-1", "!eu-*"}} {
+		if _, _, err := ParseRegions(PartitionAWS, entries); err == nil {
+			t.Errorf("ParseRegions(%q, %q): expected an error", PartitionAWS, entries)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"eu-west-1", "eu-west-1", 0},
+		{"eu-wset-1", "eu-west-1", 1},
+		{"us-east1", "us-east-1", 1},
+		{"eu-west-1", "eu-west-2", 1},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
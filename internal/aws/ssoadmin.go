@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	awssdk "github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+)
+
+// maxConcurrentPermissionSetFetches bounds the number of concurrent per-account and
+// per-permission set IAM Identity Center calls, to stay under its rate limits.
+const maxConcurrentPermissionSetFetches = 8
+
+// ssoAdminAPI is the subset of the IAM Identity Center admin SDK client this package calls.
+type ssoAdminAPI interface {
+	ssoadmin.ListInstancesAPIClient
+	ssoadmin.ListPermissionSetsProvisionedToAccountAPIClient
+	DescribePermissionSet(ctx context.Context, params *ssoadmin.DescribePermissionSetInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.DescribePermissionSetOutput, error)
+}
+
+type ssoAdminClient struct {
+	client ssoAdminAPI
+}
+
+// NewSSOAdminClient returns a client backed by the real AWS SDK, calling IAM Identity Center in
+// region, with the same retry policy as NewOrganizationsClient. It satisfies
+// generator.SSOAdminClient.
+func NewSSOAdminClient(cfg awssdk.Config, region string) *ssoAdminClient {
+	cfg.Retryer = throttledRetryer
+	cfg.Region = region
+	return &ssoAdminClient{client: ssoadmin.NewFromConfig(cfg)}
+}
+
+// ListPermissionSets returns the names of the permission sets provisioned to each of
+// accountIDs, by account ID. Accounts are listed concurrently, then every distinct permission
+// set is described once, both bounded by maxConcurrentPermissionSetFetches.
+func (c *ssoAdminClient) ListPermissionSets(ctx context.Context, accountIDs []string) (map[string][]string, error) {
+	instanceARN, err := c.instanceARN(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	arns := make([][]string, len(accountIDs))
+	err = fetchConcurrently(ctx, len(accountIDs), maxConcurrentPermissionSetFetches, func(ctx context.Context, i int) error {
+		list, err := c.listProvisionedPermissionSets(ctx, instanceARN, accountIDs[i])
+		if err != nil {
+			return fmt.Errorf("account %s: %w", accountIDs[i], err)
+		}
+		arns[i] = list
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The same permission sets are usually provisioned to many accounts: describe each once.
+	var distinct []string
+	names := make(map[string]string)
+	for _, list := range arns {
+		for _, arn := range list {
+			if _, ok := names[arn]; !ok {
+				names[arn] = ""
+				distinct = append(distinct, arn)
+			}
+		}
+	}
+	described := make([]string, len(distinct))
+	err = fetchConcurrently(ctx, len(distinct), maxConcurrentPermissionSetFetches, func(ctx context.Context, i int) error {
+		out, err := c.client.DescribePermissionSet(ctx, &ssoadmin.DescribePermissionSetInput{
+			InstanceArn:      &instanceARN,
+			PermissionSetArn: &distinct[i],
+		})
+		if err != nil {
+			return fmt.Errorf("describing permission set %s: %w", distinct[i], err)
+		}
+		if out.PermissionSet != nil {
+			described[i] = awssdk.ToString(out.PermissionSet.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, arn := range distinct {
+		names[arn] = described[i]
+	}
+
+	permissionSets := make(map[string][]string, len(accountIDs))
+	for i, id := range accountIDs {
+		for _, arn := range arns[i] {
+			permissionSets[id] = append(permissionSets[id], names[arn])
+		}
+	}
+	return permissionSets, nil
+}
+
+// instanceARN returns the ARN of the organization's IAM Identity Center instance. An
+// organization can only have one.
+func (c *ssoAdminClient) instanceARN(ctx context.Context) (string, error) {
+	var arns []string
+
+	paginator := ssoadmin.NewListInstancesPaginator(c.client, &ssoadmin.ListInstancesInput{})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("listing IAM Identity Center instances: %w", err)
+		}
+		for _, instance := range page.Instances {
+			arns = append(arns, awssdk.ToString(instance.InstanceArn))
+		}
+	}
+
+	if len(arns) != 1 {
+		return "", fmt.Errorf("found %d IAM Identity Center instances, want exactly one", len(arns))
+	}
+	return arns[0], nil
+}
+
+func (c *ssoAdminClient) listProvisionedPermissionSets(ctx context.Context, instanceARN, accountID string) ([]string, error) {
+	var arns []string
+
+	paginator := ssoadmin.NewListPermissionSetsProvisionedToAccountPaginator(c.client, &ssoadmin.ListPermissionSetsProvisionedToAccountInput{
+		InstanceArn: &instanceARN,
+		AccountId:   &accountID,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing provisioned permission sets: %w", err)
+		}
+		arns = append(arns, page.PermissionSets...)
+	}
+
+	return arns, nil
+}
@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin"
+	"github.com/aws/aws-sdk-go-v2/service/ssoadmin/types"
+)
+
+// fakeSSOAdminAPI is an in-memory ssoAdminAPI - no AWS calls happen in these tests.
+type fakeSSOAdminAPI struct {
+	instances []types.InstanceMetadata
+
+	// provisioned maps an account ID to the ARNs of its permission sets, and names a permission
+	// set ARN to its name.
+	provisioned    map[string][]string
+	provisionedErr map[string]error
+	names          map[string]string
+
+	describeCalls atomic.Int32
+}
+
+func (f *fakeSSOAdminAPI) ListInstances(ctx context.Context, params *ssoadmin.ListInstancesInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.ListInstancesOutput, error) {
+	return &ssoadmin.ListInstancesOutput{Instances: f.instances}, nil
+}
+
+func (f *fakeSSOAdminAPI) ListPermissionSetsProvisionedToAccount(ctx context.Context, params *ssoadmin.ListPermissionSetsProvisionedToAccountInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.ListPermissionSetsProvisionedToAccountOutput, error) {
+	id := *params.AccountId
+	if err := f.provisionedErr[id]; err != nil {
+		return nil, err
+	}
+	return &ssoadmin.ListPermissionSetsProvisionedToAccountOutput{PermissionSets: f.provisioned[id]}, nil
+}
+
+func (f *fakeSSOAdminAPI) DescribePermissionSet(ctx context.Context, params *ssoadmin.DescribePermissionSetInput, optFns ...func(*ssoadmin.Options)) (*ssoadmin.DescribePermissionSetOutput, error) {
+	f.describeCalls.Add(1)
+	return &ssoadmin.DescribePermissionSetOutput{
+		PermissionSet: &types.PermissionSet{Name: strPtr(f.names[*params.PermissionSetArn])},
+	}, nil
+}
+
+func oneInstance() []types.InstanceMetadata {
+	return []types.InstanceMetadata{{InstanceArn: strPtr("arn:aws:sso:::instance/ssoins-1")}}
+}
+
+func TestSSOAdminClient_ListPermissionSets(t *testing.T) {
+	api := &fakeSSOAdminAPI{
+		instances: oneInstance(),
+		provisioned: map[string][]string{
+			"111111111111": {"arn:ps-read", "arn:ps-admin"},
+			"222222222222": {"arn:ps-read"},
+		},
+		names: map[string]string{"arn:ps-read": "ReadOnlyAccess", "arn:ps-admin": "AdministratorAccess"},
+	}
+	c := &ssoAdminClient{client: api}
+
+	got, err := c.ListPermissionSets(t.Context(), []string{"111111111111", "222222222222", "333333333333"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string][]string{
+		"111111111111": {"ReadOnlyAccess", "AdministratorAccess"},
+		"222222222222": {"ReadOnlyAccess"},
+	}
+	if !maps.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("ListPermissionSets() = %v, want %v", got, want)
+	}
+	if n := api.describeCalls.Load(); n != 2 {
+		t.Errorf("DescribePermissionSet called %d times, want once per distinct permission set (2)", n)
+	}
+}
+
+func TestSSOAdminClient_ListPermissionSets_Errors(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		name string
+		api  *fakeSSOAdminAPI
+	}{
+		{name: "no instance", api: &fakeSSOAdminAPI{}},
+		{name: "account error", api: &fakeSSOAdminAPI{
+			instances:      oneInstance(),
+			provisionedErr: map[string]error{"111111111111": boom},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ssoAdminClient{client: tt.api}
+			if _, err := c.ListPermissionSets(t.Context(), []string{"111111111111"}); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
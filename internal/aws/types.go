@@ -1,10 +1,30 @@
 package aws
 
+import "time"
+
 // Account is a single AWS Organizations account as fetched from the AWS API, with its tags
-// and parent organizational unit already resolved.
+// and organizational unit ancestry already resolved.
 type Account struct {
+	ID    string
+	Name  string
+	Email string
+	ARN   string
+	// JoinedTimestamp is when the account became part of the organization, and JoinedMethod
+	// how: "INVITED" or "CREATED".
+	JoinedTimestamp time.Time
+	JoinedMethod    string
+	// OU is the ID of the account's direct parent: an organizational unit, or the organization
+	// root for accounts placed directly under it. It's always the last element of OUPath.
+	OU string
+	// OUPath is the account's full ancestry, ordered from the organization root down to its
+	// direct parent.
+	OUPath []OrganizationalUnit
+	Tags   map[string]string
+}
+
+// OrganizationalUnit is a node of the organization tree: either an organizational unit or the
+// organization root itself.
+type OrganizationalUnit struct {
 	ID   string
 	Name string
-	OU   string
-	Tags map[string]string
 }
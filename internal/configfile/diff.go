@@ -0,0 +1,231 @@
+package configfile
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change, as in diff -u.
+const diffContext = 3
+
+// maxDiffCells bounds the size of the table diffLines computes a minimal diff with. Beyond it,
+// the differing lines are shown as all removed, then all added: still a correct diff, only not
+// a minimal one.
+const maxDiffCells = 4 << 20
+
+// Diff returns a unified diff, as printed by diff -u, turning old into new, with both labeled
+// name. It returns nil if old and new are identical.
+func Diff(name string, old, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	oldLines, newLines := diffSplit(old), diffSplit(new)
+	ops := diffLines(oldLines, newLines)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", name, name)
+	for _, h := range diffHunks(ops) {
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldCount), hunkRange(h.newStart, h.newCount))
+		for _, op := range ops[h.first:h.last] {
+			line := op.line
+			out.WriteByte(op.kind)
+			out.WriteString(strings.TrimSuffix(line, "\n"))
+			out.WriteByte('\n')
+			if !strings.HasSuffix(line, "\n") {
+				out.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return out.Bytes()
+}
+
+// diffOp is one line of a diff: kept (' '), removed ('-') or added ('+').
+type diffOp struct {
+	kind byte
+	line string
+}
+
+func diffSplit(content []byte) []string {
+	var lines []string
+	for _, line := range splitLines(content) {
+		if len(line) > 0 {
+			lines = append(lines, string(line))
+		}
+	}
+	return lines
+}
+
+// diffLines returns the diff turning a into b, as a longest common subsequence of lines
+// between their common prefix and suffix.
+func diffLines(a, b []string) []diffOp {
+	var prefix, suffix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:].
+	width := len(b) + 1
+	lcs := make([]int32, (len(a)+1)*width)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*width+j] = lcs[(i+1)*width+j+1] + 1
+			} else {
+				lcs[i*width+j] = max(lcs[(i+1)*width+j], lcs[i*width+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[(i+1)*width+j] >= lcs[i*width+j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// diffHunk is a range of ops, [first, last), shown together with its line numbers.
+type diffHunk struct {
+	first, last        int
+	oldStart, oldCount int
+	newStart, newCount int
+}
+
+// diffHunks groups ops into hunks of changes with up to diffContext kept lines around them,
+// merging hunks whose context would overlap.
+func diffHunks(ops []diffOp) []diffHunk {
+	var hunks []diffHunk
+	for i := 0; i < len(ops); i++ {
+		if ops[i].kind == ' ' {
+			continue
+		}
+		first := max(i-diffContext, 0)
+		if n := len(hunks); n > 0 && first <= hunks[n-1].last {
+			first = hunks[n-1].first
+			hunks = hunks[:n-1]
+		}
+		// Extend past this change, and any other change within 2*diffContext kept lines of it.
+		last, kept := i, 0
+		for last < len(ops) && kept <= 2*diffContext {
+			if ops[last].kind == ' ' {
+				kept++
+			} else {
+				kept = 0
+			}
+			last++
+		}
+		last = min(last-kept+diffContext, len(ops))
+		hunks = append(hunks, diffHunk{first: first, last: last})
+		i = last - 1
+	}
+
+	// Number the lines, 1-based, counting each side's lines before each hunk.
+	oldLine, newLine, pos := 1, 1, 0
+	for k := range hunks {
+		h := &hunks[k]
+		for ; pos < h.first; pos++ {
+			oldLine++
+			newLine++
+		}
+		h.oldStart, h.newStart = oldLine, newLine
+		for ; pos < h.last; pos++ {
+			switch ops[pos].kind {
+			case ' ':
+				h.oldCount++
+				h.newCount++
+				oldLine++
+				newLine++
+			case '-':
+				h.oldCount++
+				oldLine++
+			case '+':
+				h.newCount++
+				newLine++
+			}
+		}
+	}
+	return hunks
+}
+
+// hunkRange formats a hunk's line range as diff -u does: an empty range starts at the line
+// before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// secretINIKeys are the keys of AWS credentials and config files whose values are secret.
+var secretINIKeys = []string{"aws_access_key_id", "aws_secret_access_key", "aws_session_token"}
+
+// redactedValue replaces the value of a secret key in RedactINI's output.
+const redactedValue = "<redacted>"
+
+// iniKeyValuePattern matches an INI "key = value" line, capturing everything up to the value,
+// the key, and the line ending.
+var iniKeyValuePattern = regexp.MustCompile(`^(\s*([^=\s]+)\s*=\s*)[^\r\n]*?(\r?\n)?$`)
+
+// RedactINI returns content, an INI file such as ~/.aws/credentials, with the value of every
+// secret key, like aws_secret_access_key, replaced by "<redacted>", so it can be diffed into
+// logs. Other lines are kept as is.
+func RedactINI(content []byte) []byte {
+	if content == nil {
+		return nil
+	}
+	var out bytes.Buffer
+	for _, line := range splitLines(content) {
+		match := iniKeyValuePattern.FindSubmatch(line)
+		if match == nil || !slices.Contains(secretINIKeys, strings.ToLower(string(match[2]))) {
+			out.Write(line)
+			continue
+		}
+		out.Write(match[1])
+		out.WriteString(redactedValue)
+		out.Write(match[3])
+	}
+	return out.Bytes()
+}
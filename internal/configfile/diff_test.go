@@ -0,0 +1,102 @@
+package configfile
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiff_Identical(t *testing.T) {
+	if got := Diff("credentials", []byte("a\nb\n"), []byte("a\nb\n")); got != nil {
+		t.Errorf("Diff() = %q, want nil", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{
+			name: "new file",
+			old:  "",
+			new:  "a\nb\n",
+			want: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "deleted content",
+			old:  "a\n",
+			new:  "",
+			want: "@@ -1 +0,0 @@\n-a\n",
+		},
+		{
+			name: "changed line with context",
+			old:  "1\n2\n3\n4\n5\n6\n7\n8\n",
+			new:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want: "@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "distant changes in separate hunks",
+			old:  "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			new:  "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want: "@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name: "close changes in one hunk",
+			old:  "a\n1\n2\n3\n4\nb\n",
+			new:  "A\n1\n2\n3\n4\nB\n",
+			want: "@@ -1,6 +1,6 @@\n-a\n+A\n 1\n 2\n 3\n 4\n-b\n+B\n",
+		},
+		{
+			name: "inserted lines",
+			old:  "a\nc\n",
+			new:  "a\nb\nc\n",
+			want: "@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+		},
+		{
+			name: "missing trailing newline",
+			old:  "a\nb",
+			new:  "a\nb\n",
+			want: "@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(Diff("file", []byte(tt.old), []byte(tt.new)))
+			want := "--- file\n+++ file\n" + tt.want
+			if got != want {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+// Beyond maxDiffCells, the diff is no longer minimal but still turns old into new.
+func TestDiff_Large(t *testing.T) {
+	var old, new strings.Builder
+	for i := range 3000 {
+		fmt.Fprintf(&old, "old %d\n", i)
+		fmt.Fprintf(&new, "new %d\n", i)
+	}
+
+	got := string(Diff("file", []byte(old.String()), []byte(new.String())))
+	if !strings.HasPrefix(got, "--- file\n+++ file\n@@ -1,3000 +1,3000 @@\n-old 0\n") {
+		t.Errorf("Diff() starts with %q", got[:min(len(got), 80)])
+	}
+	if strings.Count(got, "\n-old") != 3000 || strings.Count(got, "\n+new") != 3000 {
+		t.Error("Diff() doesn't remove every old line and add every new one")
+	}
+}
+
+func TestRedactINI(t *testing.T) {
+	content := "[default]\naws_access_key_id = AKIAEXAMPLE\nAWS_SECRET_ACCESS_KEY=wJalrSECRET\r\n  aws_session_token =  FwoSECRET\nregion = eu-west-1\n# aws_secret_access_key is a comment\naws_session_token = last"
+	want := "[default]\naws_access_key_id = <redacted>\nAWS_SECRET_ACCESS_KEY=<redacted>\r\n  aws_session_token =  <redacted>\nregion = eu-west-1\n# aws_secret_access_key is a comment\naws_session_token = <redacted>"
+	if got := string(RedactINI([]byte(content))); got != want {
+		t.Errorf("RedactINI() = %q, want %q", got, want)
+	}
+	if got := RedactINI(nil); got != nil {
+		t.Errorf("RedactINI(nil) = %q, want nil", got)
+	}
+}
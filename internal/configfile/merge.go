@@ -0,0 +1,150 @@
+// Package configfile writes the config files the CLI generates: atomically, keeping backups of
+// the files it replaces, and optionally merging into their existing content. It also diffs and
+// summarizes what a write would change. Like generator and internal/aws, it never logs: it
+// returns errors instead.
+package configfile
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// beginMarker and endMarker start the lines delimiting the managed block: the part of a file
+// this tool owns and rewrites on every run. They're "#" comments, valid in both INI and HCL
+// files, and only their prefix is matched, so the rest of the line is free-form.
+const (
+	beginMarker     = "# BEGIN steampipe-config-generator"
+	endMarker       = "# END steampipe-config-generator"
+	beginMarkerLine = beginMarker + " managed block - changes inside it are overwritten"
+	endMarkerLine   = endMarker + " managed block"
+)
+
+// MergeINI returns existing, an INI file such as ~/.aws/credentials, with its managed block
+// replaced by generated, or with a new managed block holding generated appended if it has none
+// yet. Everything outside the block is kept byte-for-byte, and sections that were generated
+// before but aren't anymore (e.g. for accounts that left the organization) disappear with the
+// old block. It fails if a section outside the block has the same name as a generated one,
+// rather than write a file with a duplicate, shadowed section.
+func MergeINI(existing, generated []byte) ([]byte, error) {
+	return merge(existing, generated, iniSections)
+}
+
+// MergeSPC is MergeINI for a Steampipe connection config file such as aws.spc: it fails if a
+// connection outside the managed block has the same name as a generated one, which Steampipe
+// would otherwise reject as a duplicate.
+func MergeSPC(existing, generated []byte) ([]byte, error) {
+	return merge(existing, generated, spcConnections)
+}
+
+// merge implements MergeINI and MergeSPC for any file format, given a function listing the names that
+// must not appear both inside and outside the managed block.
+func merge(existing, generated []byte, names func(content []byte) []string) ([]byte, error) {
+	lines := splitLines(existing)
+
+	begin, end := -1, -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(string(line))
+		switch {
+		case strings.HasPrefix(trimmed, beginMarker):
+			if begin != -1 {
+				return nil, fmt.Errorf("line %d: duplicate managed block begin marker", i+1)
+			}
+			begin = i
+		case strings.HasPrefix(trimmed, endMarker):
+			if begin == -1 || end != -1 {
+				return nil, fmt.Errorf("line %d: unexpected managed block end marker", i+1)
+			}
+			end = i
+		}
+	}
+	if begin != -1 && end == -1 {
+		return nil, fmt.Errorf("line %d: managed block begin marker has no matching end marker", begin+1)
+	}
+
+	var before, after []byte
+	if begin == -1 {
+		// Append the new block after the existing content, separated by a blank line.
+		before = slices.Clone(existing)
+		if len(before) > 0 && !bytes.HasSuffix(before, []byte("\n")) {
+			before = append(before, '\n')
+		}
+		if len(before) > 0 {
+			before = append(before, '\n')
+		}
+	} else {
+		before = bytes.Join(lines[:begin], nil)
+		after = bytes.Join(lines[end+1:], nil)
+	}
+
+	outside := slices.Concat(before, after)
+	if err := checkConflicts(names(outside), names(generated)); err != nil {
+		return nil, err
+	}
+
+	var merged bytes.Buffer
+	merged.Write(before)
+	merged.WriteString(beginMarkerLine + "\n")
+	merged.Write(generated)
+	if len(generated) > 0 && !bytes.HasSuffix(generated, []byte("\n")) {
+		merged.WriteByte('\n')
+	}
+	merged.WriteString(endMarkerLine + "\n")
+	merged.Write(after)
+	return merged.Bytes(), nil
+}
+
+func checkConflicts(outside, generated []string) error {
+	var conflicts []string
+	for _, name := range generated {
+		quoted := strconv.Quote(name)
+		if slices.Contains(outside, name) && !slices.Contains(conflicts, quoted) {
+			conflicts = append(conflicts, quoted)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%s already defined outside the managed block, remove or rename them before merging", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// iniSections returns the name of every [section] in content, with runs of whitespace
+// collapsed so that "[profile  name]" and "[profile name]" are the same section, as for the AWS
+// CLI.
+func iniSections(content []byte) []string {
+	var sections []string
+	for _, line := range splitLines(content) {
+		trimmed := strings.TrimSpace(string(line))
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			sections = append(sections, strings.Join(strings.Fields(trimmed[1:len(trimmed)-1]), " "))
+		}
+	}
+	return sections
+}
+
+// spcConnectionPattern matches the opening line of an HCL connection block, e.g.
+// `connection "aws_team_foo" {`.
+var spcConnectionPattern = regexp.MustCompile(`^\s*connection\s+"([^"]*)"`)
+
+// spcConnections returns the name of every connection block in content.
+func spcConnections(content []byte) []string {
+	var connections []string
+	for _, line := range splitLines(content) {
+		if match := spcConnectionPattern.FindSubmatch(line); match != nil {
+			connections = append(connections, string(match[1]))
+		}
+	}
+	return connections
+}
+
+// splitLines splits content into lines, each keeping its own line ending ("\n" or "\r\n"), so
+// joining them back gives content byte-for-byte.
+func splitLines(content []byte) [][]byte {
+	if len(content) == 0 {
+		return nil
+	}
+	return bytes.SplitAfter(content, []byte("\n"))
+}
@@ -0,0 +1,174 @@
+package configfile
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const generatedINI = `[team_foo]
+role_arn = arn:aws:iam::111111111111:role/my-role
+`
+
+func TestMergeINI_EmptyExisting(t *testing.T) {
+	got, err := MergeINI(nil, []byte(generatedINI))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := beginMarkerLine + "\n" + generatedINI + endMarkerLine + "\n"
+	if string(got) != want {
+		t.Errorf("merged =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMergeINI_AppendsBlock(t *testing.T) {
+	for _, existing := range []string{
+		"[default]\naws_access_key_id = AKIA\n",
+		"[default]\naws_access_key_id = AKIA",
+	} {
+		got, err := MergeINI([]byte(existing), []byte(generatedINI))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := "[default]\naws_access_key_id = AKIA\n\n" + beginMarkerLine + "\n" + generatedINI + endMarkerLine + "\n"
+		if string(got) != want {
+			t.Errorf("merged =\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+// Everything outside the block - comments, odd spacing, CRLF line endings, no trailing newline
+// - must survive a merge untouched, while profiles that are no longer generated disappear.
+func TestMergeINI_ReplacesBlock(t *testing.T) {
+	before := "# my profiles\r\n[default]\r\naws_access_key_id   =   AKIA\r\n\r\n"
+	after := "\r\n[personal]\r\n; hand-written\r\nregion=eu-west-1"
+	existing := before +
+		beginMarkerLine + "\n" +
+		"[team_foo]\nrole_arn = old\n\n[team_gone]\nrole_arn = stale\n" +
+		endMarkerLine + "\n" +
+		after
+
+	got, err := MergeINI([]byte(existing), []byte(generatedINI))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := before + beginMarkerLine + "\n" + generatedINI + endMarkerLine + "\n" + after
+	if string(got) != want {
+		t.Errorf("merged =\n%q\nwant\n%q", got, want)
+	}
+	if strings.Contains(string(got), "team_gone") {
+		t.Error("stale generated profile was kept")
+	}
+}
+
+// Merging the output of a merge again must be a no-op, so reruns don't grow the file.
+func TestMergeINI_Idempotent(t *testing.T) {
+	first, err := MergeINI([]byte("[default]\nregion = us-east-1\n"), []byte(generatedINI))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := MergeINI(first, []byte(generatedINI))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(second) != string(first) {
+		t.Errorf("second merge =\n%s\nwant\n%s", second, first)
+	}
+}
+
+func TestMergeINI_EmptyGenerated(t *testing.T) {
+	existing := "[default]\n" + beginMarkerLine + "\n[team_foo]\n" + endMarkerLine + "\n"
+
+	got, err := MergeINI([]byte(existing), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := "[default]\n" + beginMarkerLine + "\n" + endMarkerLine + "\n"
+	if string(got) != want {
+		t.Errorf("merged =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMergeINI_ConflictOutsideBlock(t *testing.T) {
+	existing := "[ team_foo ]\nrole_arn = hand-written\n"
+
+	_, err := MergeINI([]byte(existing), []byte(generatedINI))
+	if err == nil {
+		t.Fatal("expected an error for a generated profile already defined outside the block")
+	}
+	if !strings.Contains(err.Error(), `"team_foo"`) {
+		t.Errorf("error = %v, want it to name the conflicting profile", err)
+	}
+}
+
+func TestMergeINI_MalformedMarkers(t *testing.T) {
+	tests := map[string]string{
+		"begin without end": beginMarkerLine + "\n[team_foo]\n",
+		"end without begin": "[team_foo]\n" + endMarkerLine + "\n",
+		"duplicate begin":   beginMarkerLine + "\n" + beginMarkerLine + "\n" + endMarkerLine + "\n",
+		"duplicate block": beginMarkerLine + "\n" + endMarkerLine + "\n" +
+			beginMarkerLine + "\n" + endMarkerLine + "\n",
+	}
+
+	for name, existing := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := MergeINI([]byte(existing), []byte(generatedINI)); err == nil {
+				t.Error("expected an error for malformed markers")
+			}
+		})
+	}
+}
+
+func TestMergeSPC_ReplacesBlock(t *testing.T) {
+	handWritten := `connection "aws_cross_org" {
+  plugin  = "aws"
+  profile = "cross_org"
+}
+
+`
+	existing := handWritten + beginMarkerLine + "\n" +
+		"connection \"aws_team_gone\" {\n  plugin = \"aws\"\n}\n" +
+		endMarkerLine + "\n"
+	generated := "connection \"aws_team_foo\" {\n  plugin = \"aws\"\n}\n"
+
+	got, err := MergeSPC([]byte(existing), []byte(generated))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := handWritten + beginMarkerLine + "\n" + generated + endMarkerLine + "\n"
+	if string(got) != want {
+		t.Errorf("merged =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMergeSPC_ConflictOutsideBlock(t *testing.T) {
+	existing := "connection \"aws\" {\n  plugin = \"aws\"\n  type   = \"aggregator\"\n}\n"
+	generated := "connection \"aws\" {\n  plugin = \"aws\"\n}\n\nconnection \"aws_team_foo\" {\n  plugin = \"aws\"\n}\n"
+
+	_, err := MergeSPC([]byte(existing), []byte(generated))
+	if err == nil {
+		t.Fatal("expected an error for a generated connection already defined outside the block")
+	}
+	if !strings.Contains(err.Error(), `"aws"`) || strings.Contains(err.Error(), "aws_team_foo") {
+		t.Errorf("error = %v, want it to name only the conflicting connection", err)
+	}
+}
+
+func TestSPCConnections(t *testing.T) {
+	content := `# connection "commented_out" {
+connection "aws" {
+  connections = ["aws_*"]
+}
+  connection  "aws_indented" {
+}
+`
+	got := spcConnections([]byte(content))
+	if want := []string{"aws", "aws_indented"}; !slices.Equal(got, want) {
+		t.Errorf("spcConnections() = %q, want %q", got, want)
+	}
+}
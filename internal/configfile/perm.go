@@ -0,0 +1,35 @@
+package configfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// Default permissions for the files and directories the CLI creates. Credentials files may hold
+// static keys, so by default only their owner can read them.
+const (
+	DefaultFilePerm fs.FileMode = 0o600
+	DefaultDirPerm  fs.FileMode = 0o700
+)
+
+// ReadableByOthers reports whether the file or directory at path is readable by its group or by
+// other users while allowed, the permissions it's meant to have, doesn't grant them that, and
+// returns its permissions. It reports false if path doesn't exist, and always on platforms
+// without Unix permissions (see hasUnixPermissions).
+func ReadableByOthers(path string, allowed fs.FileMode) (fs.FileMode, bool, error) {
+	if !hasUnixPermissions {
+		return 0, false, nil
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	perm := info.Mode().Perm()
+	return perm, perm&0o044&^allowed != 0, nil
+}
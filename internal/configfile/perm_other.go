@@ -0,0 +1,18 @@
+//go:build !unix
+
+package configfile
+
+import (
+	"io/fs"
+	"os"
+)
+
+// hasUnixPermissions reports whether file permission bits are meaningful on this platform. On
+// Windows, Go only maps the read-only attribute onto them.
+const hasUnixPermissions = false
+
+// preserveOwner is a no-op: file ownership is managed through ACLs, which a rename within the
+// same directory doesn't reset.
+func preserveOwner(*os.File, fs.FileInfo) error {
+	return nil
+}
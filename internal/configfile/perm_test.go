@@ -0,0 +1,83 @@
+package configfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile_NewFilePermissions(t *testing.T) {
+	if !hasUnixPermissions {
+		t.Skip("no Unix permissions on this platform")
+	}
+
+	tests := []struct {
+		perm fs.FileMode
+		want fs.FileMode
+	}{
+		{0, DefaultFilePerm},
+		{0o640, 0o640},
+	}
+	for _, tt := range tests {
+		file := filepath.Join(t.TempDir(), "credentials")
+		if err := WriteFile(file, []byte("new"), WriteOptions{Perm: tt.perm}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != tt.want {
+			t.Errorf("Perm %v: permissions = %v, want %v", tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestReadableByOthers(t *testing.T) {
+	if !hasUnixPermissions {
+		t.Skip("no Unix permissions on this platform")
+	}
+
+	tests := []struct {
+		name    string
+		mode    fs.FileMode
+		allowed fs.FileMode
+		want    bool
+	}{
+		{"owner only", 0o600, 0o600, false},
+		{"group readable", 0o640, 0o600, true},
+		{"world readable", 0o604, 0o600, true},
+		{"group writable only", 0o620, 0o600, false},
+		{"readable as allowed", 0o644, 0o644, false},
+		{"readable beyond allowed", 0o644, 0o640, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "credentials")
+			if err := os.WriteFile(file, nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			// Chmod explicitly, since os.WriteFile's permissions are subject to the umask.
+			if err := os.Chmod(file, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+
+			perm, got, err := ReadableByOthers(file, tt.allowed)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want || perm != tt.mode {
+				t.Errorf("ReadableByOthers() = %v, %v, want %v, %v", perm, got, tt.mode, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadableByOthers_Missing(t *testing.T) {
+	_, got, err := ReadableByOthers(filepath.Join(t.TempDir(), "missing"), DefaultFilePerm)
+	if err != nil || got {
+		t.Errorf("ReadableByOthers() = %v, %v, want false, nil", got, err)
+	}
+}
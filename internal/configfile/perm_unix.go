@@ -0,0 +1,38 @@
+//go:build unix
+
+package configfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// hasUnixPermissions reports whether file permission bits are meaningful on this platform.
+const hasUnixPermissions = true
+
+// chown changes the owner and group of f. It's a variable for tests to make it fail.
+var chown = (*os.File).Chown
+
+// preserveOwner gives f the owner and group of the file described by info, if they differ. This
+// only happens when replacing a file owned by someone else, e.g. when running as root. It's
+// best-effort: if the chown isn't permitted, e.g. for a file whose group the user isn't a member
+// of, f keeps the user's owner and group.
+func preserveOwner(f *os.File, info fs.FileInfo) error {
+	want, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	current, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if got, ok := current.Sys().(*syscall.Stat_t); ok && got.Uid == want.Uid && got.Gid == want.Gid {
+		return nil
+	}
+	if err := chown(f, int(want.Uid), int(want.Gid)); err != nil && !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	return nil
+}
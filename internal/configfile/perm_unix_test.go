@@ -0,0 +1,73 @@
+//go:build unix
+
+package configfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// Replacing a file owned by another user, as root, must keep it owned by that user.
+func TestWriteFile_PreservesOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing a file's owner requires root")
+	}
+
+	file := filepath.Join(t.TempDir(), "credentials")
+	if err := os.WriteFile(file, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	const uid, gid = 1234, 5678
+	if err := os.Chown(file, uid, gid); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(file, []byte("new"), WriteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+	if st.Uid != uid || st.Gid != gid {
+		t.Errorf("owner = %d:%d, want %d:%d", st.Uid, st.Gid, uid, gid)
+	}
+}
+
+// A file whose owner can't be kept, e.g. one chgrp'd to a group the user isn't in, is still
+// replaced.
+func TestWriteFile_OwnerNotPermitted(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("giving a file another owner requires root")
+	}
+
+	file := filepath.Join(t.TempDir(), "aws.spc")
+	if err := os.WriteFile(file, []byte("old"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chown(file, 1234, 5678); err != nil {
+		t.Fatal(err)
+	}
+	// Running as root, chown is permitted: make it fail as it would for another user.
+	calls := 0
+	chown = func(f *os.File, uid, gid int) error {
+		calls++
+		return &fs.PathError{Op: "chown", Path: f.Name(), Err: syscall.EPERM}
+	}
+	t.Cleanup(func() { chown = (*os.File).Chown })
+
+	if err := WriteFile(file, []byte("new"), WriteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := readFile(t, file); got != "new" {
+		t.Errorf("content = %q, want %q", got, "new")
+	}
+	if calls != 1 {
+		t.Errorf("chown called %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,185 @@
+package configfile
+
+import (
+	"bytes"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Changes lists what was added, removed and changed between two versions of a set of named
+// entries, each sorted by name.
+type Changes struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// Empty reports whether there are no changes at all.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// Summary is what changes between two versions of the files a run writes.
+type Summary struct {
+	// Accounts are keyed by profile name. An account changes if its profile does, in the
+	// credentials or config file, or its connection: any non-aggregator connection, matched to
+	// its profile by its profile argument, or by its own name if it has none.
+	Accounts Changes
+	// Aggregators are the connections of type "aggregator", keyed by connection name.
+	Aggregators Changes
+}
+
+// Contents is the content of the files a run writes, nil for any it doesn't write.
+type Contents struct {
+	Credentials []byte
+	Config      []byte
+	Connections []byte
+}
+
+// Summarize compares the old and new content of the files a run writes.
+func Summarize(old, new Contents) Summary {
+	oldAccounts, oldAggregators := contentBlocks(old)
+	newAccounts, newAggregators := contentBlocks(new)
+	return Summary{
+		Accounts:    compareBlocks(oldAccounts, newAccounts),
+		Aggregators: compareBlocks(oldAggregators, newAggregators),
+	}
+}
+
+// contentBlocks returns the blocks of every account, keyed by profile name, and of every
+// aggregator in contents.
+func contentBlocks(contents Contents) (accounts, aggregators map[string][]string) {
+	accounts, aggregators = spcBlocks(contents.Connections)
+	// An account is its profiles and connection together: key them all by profile name.
+	for _, profiles := range [][]byte{contents.Credentials, contents.Config} {
+		for name, block := range iniBlocks(profiles) {
+			accounts[name] = append(accounts[name], block)
+		}
+	}
+	return accounts, aggregators
+}
+
+func compareBlocks(old, new map[string][]string) Changes {
+	var changes Changes
+	for _, name := range slices.Sorted(maps.Keys(new)) {
+		oldBlocks, ok := old[name]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, name)
+		case !slices.Equal(sortedBlocks(oldBlocks), sortedBlocks(new[name])):
+			changes.Changed = append(changes.Changed, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := new[name]; !ok {
+			changes.Removed = append(changes.Removed, name)
+		}
+	}
+	return changes
+}
+
+func sortedBlocks(blocks []string) []string {
+	return slices.Sorted(slices.Values(blocks))
+}
+
+// iniBlocks returns the content of every [section] in content, by section name, with
+// surrounding whitespace trimmed. A "[profile name]" section, as in an AWS config file, is
+// keyed by its profile name alone, and "[sso-session name]" sections, which aren't profiles,
+// are left out.
+func iniBlocks(content []byte) map[string]string {
+	blocks := make(map[string]string)
+	var name string
+	var block strings.Builder
+	flush := func() {
+		if name != "" {
+			blocks[name] = strings.TrimSpace(block.String())
+		}
+		block.Reset()
+	}
+	for _, line := range splitLines(content) {
+		trimmed := strings.TrimSpace(string(line))
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			flush()
+			name = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			if profile, ok := strings.CutPrefix(name, "profile "); ok {
+				name = strings.TrimSpace(profile)
+			} else if strings.HasPrefix(name, "sso-session ") {
+				name = ""
+			}
+		}
+		if !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, ";") {
+			block.WriteString(trimmed + "\n")
+		}
+	}
+	flush()
+	return blocks
+}
+
+var (
+	spcAggregatorPattern = regexp.MustCompile(`(?m)^\s*type\s*=\s*"aggregator"`)
+	spcProfilePattern    = regexp.MustCompile(`(?m)^\s*profile\s*=\s*"([^"]*)"`)
+)
+
+// spcBlocks returns the content of every connection block in content, split between account
+// connections, keyed by their profile argument (see Summary.Accounts), and aggregator
+// connections, keyed by name. Account names map to a slice so Summarize can add the account's
+// profile to it.
+func spcBlocks(content []byte) (accounts, aggregators map[string][]string) {
+	accounts, aggregators = make(map[string][]string), make(map[string][]string)
+
+	lines := splitLines(content)
+	for i := 0; i < len(lines); i++ {
+		match := spcConnectionPattern.FindSubmatch(lines[i])
+		if match == nil {
+			continue
+		}
+		name := string(match[1])
+
+		// The block ends on the line where its braces balance out.
+		var block bytes.Buffer
+		depth := 0
+		for ; i < len(lines); i++ {
+			block.Write(bytes.TrimSpace(lines[i]))
+			block.WriteByte('\n')
+			depth += braceDepth(lines[i])
+			if depth <= 0 {
+				break
+			}
+		}
+
+		text := block.String()
+		if spcAggregatorPattern.MatchString(text) {
+			aggregators[name] = []string{text}
+			continue
+		}
+		if profile := spcProfilePattern.FindStringSubmatch(text); profile != nil {
+			name = profile[1]
+		}
+		accounts[name] = append(accounts[name], text)
+	}
+	return accounts, aggregators
+}
+
+// braceDepth returns how many more "{" than "}" line has, ignoring those in strings and
+// comments.
+func braceDepth(line []byte) int {
+	depth, inString := 0, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '#':
+			return depth
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		}
+	}
+	return depth
+}
@@ -0,0 +1,149 @@
+package configfile
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	oldCredentials := `[default]
+region = eu-west-1
+
+# BEGIN steampipe-config-generator managed block
+[team_foo]
+role_arn = arn:aws:iam::111111111111:role/old-role
+
+[team_bar]
+role_arn = arn:aws:iam::222222222222:role/my-role
+
+[team_gone]
+role_arn = arn:aws:iam::333333333333:role/my-role
+# END steampipe-config-generator managed block
+`
+	newCredentials := `[default]
+region = eu-west-1
+
+# BEGIN steampipe-config-generator managed block
+[team_bar]
+role_arn = arn:aws:iam::222222222222:role/my-role
+
+[team_foo]
+role_arn = arn:aws:iam::111111111111:role/my-role
+
+[team_new]
+role_arn = arn:aws:iam::444444444444:role/my-role
+
+[team_regions]
+role_arn = arn:aws:iam::555555555555:role/my-role
+# END steampipe-config-generator managed block
+`
+	oldConnections := `connection "aws" {
+  plugin      = "aws"
+  type        = "aggregator"
+  connections = ["aws_*"]
+}
+
+connection "aws_team_env" {
+  type        = "aggregator"
+  connections = ["aws_team_foo"]
+}
+
+connection "aws_team_regions" {
+  plugin  = "aws"
+  profile = "team_regions"
+  regions = ["*"]
+}
+`
+	newConnections := `connection "aws" {
+  plugin      = "aws"
+  type        = "aggregator"
+  connections = ["aws_*"]
+}
+
+connection "aws_team_env" {
+  type        = "aggregator"
+  connections = ["aws_team_foo", "aws_team_new"]
+}
+
+connection "aws_ou_new" {
+  type        = "aggregator"
+  connections = ["aws_team_new"]
+}
+
+connection "aws_team_regions" {
+  plugin  = "aws"
+  profile = "team_regions"
+  regions = ["eu-west-1"]
+}
+`
+
+	got := Summarize(
+		Contents{Credentials: []byte(oldCredentials), Connections: []byte(oldConnections)},
+		Contents{Credentials: []byte(newCredentials), Connections: []byte(newConnections)},
+	)
+
+	want := Summary{
+		Accounts: Changes{
+			Added:   []string{"team_new"},
+			Removed: []string{"team_gone"},
+			Changed: []string{"team_foo", "team_regions"},
+		},
+		Aggregators: Changes{
+			Added:   []string{"aws_ou_new"},
+			Changed: []string{"aws_team_env"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Summarize() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSummarize_Unchanged(t *testing.T) {
+	credentials := []byte("[team_foo]\nrole_arn = x\n")
+	connections := []byte("connection \"aws_team_foo\" {\n  profile = \"team_foo\"\n}\n")
+
+	contents := Contents{Credentials: credentials, Connections: connections}
+	got := Summarize(contents, contents)
+	if !got.Accounts.Empty() || !got.Aggregators.Empty() {
+		t.Errorf("Summarize() = %+v, want no changes", got)
+	}
+}
+
+// A profile in the config file is the same account as in the credentials file, and changing
+// either changes the account.
+func TestSummarize_Config(t *testing.T) {
+	credentials := []byte("[team_foo]\nrole_arn = x\n")
+	old := Contents{Credentials: credentials, Config: []byte("[profile team_foo]\nregion = eu-west-1\n")}
+	new := Contents{Credentials: credentials, Config: []byte("[profile team_foo]\nregion = us-east-1\n")}
+
+	got := Summarize(old, new)
+	if want := (Changes{Changed: []string{"team_foo"}}); !reflect.DeepEqual(got.Accounts, want) {
+		t.Errorf("Summarize().Accounts = %+v, want %+v", got.Accounts, want)
+	}
+}
+
+// An sso-session block is shared by SSO profiles, not an account of its own.
+func TestSummarize_SSOSession(t *testing.T) {
+	old := Contents{Config: []byte("[profile team_foo]\nsso_session = steampipe\n")}
+	new := Contents{Config: []byte("[sso-session steampipe]\nsso_region = eu-west-1\n\n[profile team_foo]\nsso_session = steampipe\n")}
+
+	got := Summarize(old, new)
+	if !got.Accounts.Empty() {
+		t.Errorf("Summarize().Accounts = %+v, want no changes", got.Accounts)
+	}
+}
+
+func TestBraceDepth(t *testing.T) {
+	tests := map[string]int{
+		`connection "aws" {`:             1,
+		`}`:                              -1,
+		`  options = { a = "{" }`:        0,
+		`  name = "\"{"`:                 0,
+		`connection "x" { # { comment }`: 1,
+	}
+	for line, want := range tests {
+		if got := braceDepth([]byte(line)); got != want {
+			t.Errorf("braceDepth(%q) = %d, want %d", line, got, want)
+		}
+	}
+}
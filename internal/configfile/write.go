@@ -0,0 +1,229 @@
+package configfile
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the timestamp embedded in backup file names. It's fixed-width, so backups
+// of the same file sort chronologically by name.
+const backupTimeFormat = "20060102T150405.000Z"
+
+// backupSuffix ends every backup file name. Backups mustn't keep their original extension:
+// Steampipe loads every *.spc file in its config directory, so an "aws.spc" backup named
+// "aws.spc.<time>.spc" would be loaded as duplicate connections.
+const backupSuffix = ".bak"
+
+// Backup is a timestamped copy of a file, made by WriteFile before replacing it.
+type Backup struct {
+	// Path is the backup file's path.
+	Path string
+	// Time is when the backup was made. Backups made in the same run share the same Time.
+	Time time.Time
+}
+
+// BackupID returns the identifier of backups made at t, as embedded in their file names and
+// accepted by FindBackup.
+func BackupID(t time.Time) string {
+	return t.UTC().Format(backupTimeFormat)
+}
+
+// WriteOptions configures WriteFile.
+type WriteOptions struct {
+	// Perm is the permissions of the file if it's created, DefaultFilePerm if zero. An existing
+	// file keeps its own.
+	Perm fs.FileMode
+	// Backups is how many backups of the file to keep. Before being replaced, the current file
+	// is copied to a backup named after BackupTime, and the oldest backups beyond this count are
+	// deleted. Zero disables backups, and leaves existing ones untouched.
+	Backups int
+	// BackupTime names the backup. Passing the same time for every file written in one run lets
+	// them all be restored together.
+	BackupTime time.Time
+}
+
+// WriteFile atomically replaces file with data: data is written and synced to a temporary file
+// in the same directory, which is then renamed over file. A failure at any point leaves file as
+// it was, instead of truncated or half-written. The new file keeps the permissions of the one it
+// replaces, if any, and its owner if permitted (see preserveOwner). If file is a symlink, as set up by dotfile managers, the file it
+// points to is replaced instead, and backed up next to it.
+func WriteFile(file string, data []byte, opts WriteOptions) error {
+	file, err := resolveSymlinks(file)
+	if err != nil {
+		return err
+	}
+
+	if opts.Backups > 0 {
+		if err := backup(file, opts.BackupTime, opts.Backups); err != nil {
+			return err
+		}
+	}
+
+	dir, base := filepath.Split(file)
+	// A leading "." and no ".spc" extension keep Steampipe from loading a leftover temp file.
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating temporary file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	defer func() { _ = tmp.Close() }()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("writing temporary file: %w", err)
+	}
+	// Keep the replaced file's permissions and owner: the rename would otherwise reset them to
+	// the temporary file's.
+	perm := cmp.Or(opts.Perm, DefaultFilePerm)
+	info, err := os.Stat(file)
+	switch {
+	case err == nil:
+		perm = info.Mode().Perm()
+		if err := preserveOwner(tmp, info); err != nil {
+			return fmt.Errorf("preserving the owner of %s: %w", file, err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fmt.Errorf("setting temporary file permissions: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("syncing temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("closing temporary file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return fmt.Errorf("replacing %s: %w", file, err)
+	}
+	syncDir(dir)
+	return nil
+}
+
+// resolveSymlinks returns the path file points to if it's a symlink, file itself otherwise or if
+// it doesn't exist yet.
+func resolveSymlinks(file string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", file, err)
+	}
+	return resolved, nil
+}
+
+// backup copies file, if it exists, to a backup named after t, then deletes all but the keep
+// most recent backups of file.
+func backup(file string, t time.Time, keep int) error {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading %s to back it up: %w", file, err)
+	}
+
+	if err := os.WriteFile(backupPath(file, t), data, 0o600); err != nil {
+		return fmt.Errorf("backing up %s: %w", file, err)
+	}
+
+	backups, err := ListBackups(file)
+	if err != nil {
+		return err
+	}
+	for _, old := range backups[min(keep, len(backups)):] {
+		if err := os.Remove(old.Path); err != nil {
+			return fmt.Errorf("deleting old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+func backupPath(file string, t time.Time) string {
+	return file + "." + BackupID(t) + backupSuffix
+}
+
+// ListBackups returns file's backups, most recent first. Those of a symlink are the backups of
+// the file it points to, as made by WriteFile.
+func ListBackups(file string) ([]Backup, error) {
+	file, err := resolveSymlinks(file)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(file))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing backups of %s: %w", file, err)
+	}
+
+	prefix := filepath.Base(file) + "."
+	var backups []Backup
+	for _, entry := range entries {
+		id, ok := strings.CutPrefix(entry.Name(), prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if id, ok = strings.CutSuffix(id, backupSuffix); !ok {
+			continue
+		}
+		t, err := time.Parse(backupTimeFormat, id)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Path: filepath.Join(filepath.Dir(file), entry.Name()), Time: t})
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return cmp.Or(b.Time.Compare(a.Time), cmp.Compare(b.Path, a.Path))
+	})
+	return backups, nil
+}
+
+// FindBackup returns file's backup with the given BackupID, and whether there is one.
+func FindBackup(file, id string) (Backup, bool, error) {
+	backups, err := ListBackups(file)
+	if err != nil {
+		return Backup{}, false, err
+	}
+	for _, b := range backups {
+		if BackupID(b.Time) == id {
+			return b, true, nil
+		}
+	}
+	return Backup{}, false, nil
+}
+
+// Restore atomically replaces file with the content of b. Per opts, the current file is backed
+// up first, like WriteFile does, so a restore can itself be rolled back.
+func Restore(file string, b Backup, opts WriteOptions) error {
+	data, err := os.ReadFile(b.Path)
+	if err != nil {
+		return fmt.Errorf("reading backup: %w", err)
+	}
+	return WriteFile(file, data, opts)
+}
+
+// syncDir flushes a rename in dir to disk. It's best-effort: some platforms, like Windows, can't
+// sync a directory, and the rename itself already succeeded.
+func syncDir(dir string) {
+	if dir == "" {
+		dir = "."
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
@@ -0,0 +1,198 @@
+package configfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readFile(t *testing.T, file string) string {
+	t.Helper()
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("reading %s: %v", file, err)
+	}
+	return string(data)
+}
+
+func TestWriteFile_CreatesFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials")
+
+	if err := WriteFile(file, []byte("new"), WriteOptions{Backups: 3, BackupTime: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := readFile(t, file); got != "new" {
+		t.Errorf("content = %q, want %q", got, "new")
+	}
+	if backups, _ := ListBackups(file); len(backups) != 0 {
+		t.Errorf("backups = %v, want none for a file that didn't exist", backups)
+	}
+}
+
+// Only the target file and its backups may be left behind: no temporary files.
+func TestWriteFile_ReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "aws.spc")
+	if err := os.WriteFile(file, []byte("old"), 0o640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(file, []byte("new"), WriteOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := readFile(t, file); got != "new" {
+		t.Errorf("content = %q, want %q", got, "new")
+	}
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0o640 {
+		t.Errorf("permissions = %v, want the replaced file's %v", got, os.FileMode(0o640))
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the written file", len(entries))
+	}
+}
+
+// A symlinked file, as set up by dotfile managers, stays a symlink: the file it points to is the
+// one replaced and backed up.
+func TestWriteFile_FollowsSymlink(t *testing.T) {
+	dotfiles, home := t.TempDir(), t.TempDir()
+	target := filepath.Join(dotfiles, "credentials")
+	if err := os.WriteFile(target, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(home, "credentials")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("creating a symlink: %v", err)
+	}
+
+	if err := WriteFile(link, []byte("new"), WriteOptions{Backups: 1, BackupTime: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("%s is no longer a symlink (%v)", link, err)
+	}
+	if got := readFile(t, target); got != "new" {
+		t.Errorf("target content = %q, want %q", got, "new")
+	}
+	backups, err := ListBackups(link)
+	if err != nil || len(backups) != 1 || filepath.Dir(backups[0].Path) != dotfiles {
+		t.Errorf("backups = %v, %v, want one next to the target", backups, err)
+	}
+	if entries, _ := os.ReadDir(home); len(entries) != 1 {
+		t.Errorf("link directory has %d entries, want only the link", len(entries))
+	}
+}
+
+func TestWriteFile_MissingDirectory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "missing", "credentials")
+
+	if err := WriteFile(file, []byte("new"), WriteOptions{}); err == nil {
+		t.Fatal("expected an error for a missing directory")
+	}
+}
+
+func TestWriteFile_KeepsBackups(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "aws.spc")
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// Write version 0 to 4, keeping 3 backups: only versions 1 to 3 are left in backups.
+	for i := range 5 {
+		opts := WriteOptions{Backups: 3, BackupTime: start.Add(time.Duration(i) * time.Minute)}
+		if err := WriteFile(file, []byte{byte('0' + i)}, opts); err != nil {
+			t.Fatalf("write %d: unexpected error: %v", i, err)
+		}
+	}
+
+	backups, err := ListBackups(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backups) != 3 {
+		t.Fatalf("got %d backups, want 3", len(backups))
+	}
+	for i, want := range []string{"3", "2", "1"} {
+		if got := readFile(t, backups[i].Path); got != want {
+			t.Errorf("backup %d content = %q, want %q", i, got, want)
+		}
+	}
+	if want := start.Add(4 * time.Minute); !backups[0].Time.Equal(want) {
+		t.Errorf("latest backup time = %v, want %v", backups[0].Time, want)
+	}
+	if want := file + ".20260102T030805.000Z.bak"; backups[0].Path != want {
+		t.Errorf("latest backup path = %q, want %q", backups[0].Path, want)
+	}
+}
+
+func TestListBackups_IgnoresOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "aws.spc")
+	for _, name := range []string{
+		"aws.spc",
+		"aws.spc.not-a-time.bak",
+		"aws.spc.20260102T030405.000Z",
+		"other.spc.20260102T030405.000Z.bak",
+		".aws.spc.tmp-123",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backups, err := ListBackups(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(backups) != 0 {
+		t.Errorf("backups = %v, want none", backups)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "credentials")
+	first := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, content := range []string{"v1", "v2"} {
+		opts := WriteOptions{Backups: 5, BackupTime: first.Add(time.Duration(i) * time.Minute)}
+		if err := WriteFile(file, []byte(content), opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, ok, err := FindBackup(file, BackupID(first.Add(time.Minute)))
+	if err != nil || !ok {
+		t.Fatalf("FindBackup() = %v, %v, want the backup of v1", ok, err)
+	}
+	restoreTime := first.Add(time.Hour)
+	if err := Restore(file, b, WriteOptions{Backups: 5, BackupTime: restoreTime}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := readFile(t, file); got != "v1" {
+		t.Errorf("content = %q, want %q", got, "v1")
+	}
+	// The replaced v2 is backed up too, so the restore can be undone.
+	undo, ok, err := FindBackup(file, BackupID(restoreTime))
+	if err != nil || !ok {
+		t.Fatalf("FindBackup() = %v, %v, want the backup of v2", ok, err)
+	}
+	if got := readFile(t, undo.Path); got != "v2" {
+		t.Errorf("backup content = %q, want %q", got, "v2")
+	}
+}
+
+func TestFindBackup_NotFound(t *testing.T) {
+	_, ok, err := FindBackup(filepath.Join(t.TempDir(), "credentials"), "20260102T030405.000Z")
+	if err != nil || ok {
+		t.Errorf("FindBackup() = %v, %v, want false, nil", ok, err)
+	}
+}
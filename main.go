@@ -1,30 +1,70 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
 
 	"github.com/unicrons/steampipe-config-generator/cmd"
 	"github.com/unicrons/steampipe-config-generator/generator"
+	"github.com/unicrons/steampipe-config-generator/internal/configfile"
 )
 
 // newGenerator abstracts generator.New so tests can inject a fake Generator instead of
 // hitting real AWS. Production code always passes generator.New itself.
 type newGeneratorFunc func(ctx context.Context, opts generator.Options) (generator.Generator, error)
 
-func run(ctx context.Context, log *slog.Logger, flags *cmd.Flags, newGenerator newGeneratorFunc) error {
+// errChangesPending is returned by a --dryRun run that found changes to write, so main exits
+// with exitChangesPending.
+var errChangesPending = errors.New("dry run: changes pending")
+
+// exitChangesPending is the exit code of a --dryRun run that found changes to write. It lets a
+// CI job tell pending changes apart from both success (0) and failure (1).
+const exitChangesPending = 2
+
+// run generates the config files for flags. Only a --dryRun run writes to out.
+func run(ctx context.Context, log *slog.Logger, out io.Writer, flags *cmd.Flags, newGenerator newGeneratorFunc) error {
 	gen, err := newGenerator(ctx, generator.Options{
 		AssumeRoleArn:    flags.AssumeRoleArn,
 		Region:           flags.DefaultRegion,
 		RoleName:         flags.RoleName,
+		RolePath:         flags.RolePath,
+		RoleRules:        roleRules(flags.RoleRules),
 		CredentialSource: flags.CredentialSource,
-		ImportSchema:     flags.ImportSchema,
-		TargetRegions:    flags.TargetRegions,
-		SkipOUs:          flags.SkipOUs,
-		TagSplit:         flags.TagSplit,
+		SourceProfile:    flags.SourceProfile,
+		SSOStartURL:      flags.SSOStartURL,
+		SSORegion:        flags.SSORegion,
+		SSOSessionName:   flags.SSOSession,
+		PermissionSets:   flags.PermissionSets,
+		AssumeRole: generator.AssumeRoleOptions{
+			ExternalID:      flags.ExternalID,
+			DurationSeconds: flags.DurationSeconds,
+			RoleSessionName: flags.RoleSessionName,
+			MFASerial:       flags.MFASerial,
+		},
+		OverrideTagPrefix: flags.OverrideTagPrefix,
+		ImportSchema:      flags.ImportSchema,
+		TargetRegions:     flags.TargetRegions,
+		RegionDiscovery:   flags.DiscoverRegions,
+		IncludeOUs:        flags.IncludeOUs,
+		SkipOUs:           flags.SkipOUs,
+		TagSplit:          flags.TagSplit,
+		TagFilter:         flags.TagFilter,
+		IncludeAccounts:   flags.IncludeAccounts,
+		ExcludeAccounts:   flags.ExcludeAccounts,
+		NameCollision:     flags.NameCollision,
+		NamingScheme:      flags.NamingScheme,
 	})
 	if err != nil {
 		return fmt.Errorf("creating generator: %w", err)
@@ -34,75 +74,374 @@ func run(ctx context.Context, log *slog.Logger, flags *cmd.Flags, newGenerator n
 	if err != nil {
 		return err
 	}
+	for _, acc := range accounts {
+		if acc.CollidedName != "" {
+			log.Warn("resolved account name collision", "account", acc.ID, "collided", acc.CollidedName, "name", acc.Name)
+		}
+		if len(acc.Overrides) > 0 {
+			log.Info("applied account tag overrides", "account", acc.ID, "name", acc.Name, "overrides", acc.Overrides)
+		}
+		if len(acc.UnknownRegions) > 0 {
+			log.Warn("account tag overrides set regions unknown to this build, using them as is", "account", acc.ID, "name", acc.Name, "regions", acc.UnknownRegions)
+		}
+	}
 
-	credentialsFile := filepath.Join(flags.CredentialPath, "credentials")
-	if err := writeCredentialsFile(flags.CredentialPath, accounts); err != nil {
-		return err
+	configOpts := generator.ConfigOptions{IncludeRegion: flags.ProfileRegion}
+	connectionsOpts := generator.ConnectionsOptions{
+		AggregateByTag:   flags.AggregateByTag,
+		AggregateByOU:    flags.AggregateByOU,
+		AggregatorPrefix: flags.AggregatorPrefix,
 	}
-	log.Info("wrote AWS credentials file", "path", credentialsFile)
 
-	connectionsFile := filepath.Join(flags.ConnectionsPath, "aws.spc")
-	if err := writeConnectionsFile(flags.ConnectionsPath, flags.TemplatePath, accounts); err != nil {
+	// Every file written in this run is backed up under the same time, so restore can roll
+	// them all back together.
+	backupTime := time.Now()
+
+	// Every file is rendered before any is written, so a failing one, e.g. a custom template
+	// referencing an unknown field, leaves them all as they were.
+	files, err := planFiles(flags, accounts, configOpts, connectionsOpts, backupTime)
+	if err != nil {
 		return err
 	}
-	log.Info("wrote Steampipe connections file", "path", connectionsFile)
+	if flags.DryRun {
+		return dryRun(out, files)
+	}
+
+	for _, dir := range slices.Compact([]string{flags.CredentialPath, flags.ConnectionsPath}) {
+		warnReadableByOthers(log, dir, flags.DirMode)
+	}
+	for _, f := range files.all() {
+		warnReadableByOthers(log, f.path, f.opts.Perm)
+	}
+	for _, f := range files.all() {
+		// Rewriting an unchanged file would only rotate a real backup out for a copy of it.
+		if !f.changed() {
+			log.Info(f.description+" unchanged", "path", f.path)
+			continue
+		}
+		if err := f.write(); err != nil {
+			return err
+		}
+		log.Info("wrote "+f.description, "path", f.path)
+	}
 
 	log.Info("config files created successfully")
 	return nil
 }
 
-func writeCredentialsFile(path string, accounts []generator.Account) error {
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
-		return fmt.Errorf("creating aws credentials path: %w", err)
+// plannedFiles are the files a run writes, rendered but not written yet. credentials and
+// config are nil if the run doesn't write them.
+type plannedFiles struct {
+	credentials *plannedFile
+	config      *plannedFile
+	connections *plannedFile
+}
+
+// all returns the files planned, in the order they're written.
+func (p plannedFiles) all() []*plannedFile {
+	var files []*plannedFile
+	for _, f := range []*plannedFile{p.credentials, p.config, p.connections} {
+		if f != nil {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// planFiles renders every file run writes for flags, without writing any.
+func planFiles(flags *cmd.Flags, accounts []generator.Account, configOpts generator.ConfigOptions, connectionsOpts generator.ConnectionsOptions, backupTime time.Time) (plannedFiles, error) {
+	var files plannedFiles
+
+	if flags.WritesCredentials() {
+		f, err := planCredentialsFile(flags.CredentialPath, accounts, fileOptions{
+			merge:        flags.MergeCredentials,
+			dirPerm:      flags.DirMode,
+			WriteOptions: configfile.WriteOptions{Perm: flags.CredentialsMode, Backups: flags.Backups, BackupTime: backupTime},
+		})
+		if err != nil {
+			return plannedFiles{}, err
+		}
+		files.credentials = &f
 	}
 
-	file, err := os.Create(filepath.Join(path, "credentials"))
+	if flags.WritesConfig() {
+		f, err := planConfigFile(flags.CredentialPath, accounts, configOpts, fileOptions{
+			merge:        flags.MergeConfig,
+			dirPerm:      flags.DirMode,
+			WriteOptions: configfile.WriteOptions{Perm: flags.CredentialsMode, Backups: flags.Backups, BackupTime: backupTime},
+		})
+		if err != nil {
+			return plannedFiles{}, err
+		}
+		files.config = &f
+	}
+
+	f, err := planConnectionsFile(flags.ConnectionsPath, flags.TemplatePath, accounts, connectionsOpts, fileOptions{
+		merge:        flags.MergeConnections,
+		dirPerm:      flags.DirMode,
+		WriteOptions: configfile.WriteOptions{Perm: flags.ConnectionsMode, Backups: flags.Backups, BackupTime: backupTime},
+	})
 	if err != nil {
-		return fmt.Errorf("creating aws credentials file: %w", err)
+		return plannedFiles{}, err
 	}
-	defer func() { _ = file.Close() }()
+	files.connections = &f
 
-	if err := generator.RenderCredentials(file, accounts); err != nil {
-		return fmt.Errorf("rendering aws credentials file: %w", err)
+	return files, nil
+}
+
+// dryRun prints to out what run would change in files, as a unified diff of each followed by
+// a summary of the accounts and aggregators added, removed or changed, without writing
+// anything. It returns errChangesPending if there's any change.
+func dryRun(out io.Writer, files plannedFiles) error {
+	var diff []byte
+	for _, f := range files.all() {
+		diff = append(diff, f.diff()...)
 	}
 
-	if err := file.Close(); err != nil {
-		return fmt.Errorf("closing aws credentials file: %w", err)
+	if len(diff) == 0 {
+		_, err := fmt.Fprintln(out, "No changes.")
+		return err
 	}
-	return nil
+
+	old, new := files.contents()
+	summary := configfile.Summarize(old, new)
+	var report bytes.Buffer
+	report.Write(diff)
+	report.WriteString("\n")
+	writeChanges(&report, "Accounts", summary.Accounts)
+	writeChanges(&report, "Aggregators", summary.Aggregators)
+	if _, err := out.Write(report.Bytes()); err != nil {
+		return err
+	}
+	return errChangesPending
 }
 
-func writeConnectionsFile(path, templatePath string, accounts []generator.Account) error {
-	tmpl, err := generator.ParseConnectionsTemplate(templatePath)
+// contents returns the current and planned content of files, nil for any not planned.
+func (p plannedFiles) contents() (old, new configfile.Contents) {
+	if p.credentials != nil {
+		old.Credentials, new.Credentials = p.credentials.existing, p.credentials.content
+	}
+	if p.config != nil {
+		old.Config, new.Config = p.config.existing, p.config.content
+	}
+	if p.connections != nil {
+		old.Connections, new.Connections = p.connections.existing, p.connections.content
+	}
+	return old, new
+}
+
+// writeChanges writes one section of the --dryRun summary, e.g.:
+//
+//	Accounts: 1 added, 0 removed, 1 changed
+//	  + team_bar
+//	  ~ team_foo
+func writeChanges(w *bytes.Buffer, title string, changes configfile.Changes) {
+	fmt.Fprintf(w, "%s: %d added, %d removed, %d changed\n", title, len(changes.Added), len(changes.Removed), len(changes.Changed))
+	for _, name := range changes.Added {
+		fmt.Fprintf(w, "  + %s\n", name)
+	}
+	for _, name := range changes.Removed {
+		fmt.Fprintf(w, "  - %s\n", name)
+	}
+	for _, name := range changes.Changed {
+		fmt.Fprintf(w, "  ~ %s\n", name)
+	}
+}
+
+// fileOptions configures how a file planned by planCredentialsFile, planConfigFile or
+// planConnectionsFile is merged and written.
+type fileOptions struct {
+	// merge replaces only the managed block of the existing file with the generated content,
+	// instead of overwriting the whole file.
+	merge bool
+	// dirPerm is the permissions of the file's directory if it's created,
+	// configfile.DefaultDirPerm if zero.
+	dirPerm fs.FileMode
+	configfile.WriteOptions
+}
+
+func (o fileOptions) mkdirAll(path string) error {
+	return os.MkdirAll(path, cmp.Or(o.dirPerm, configfile.DefaultDirPerm))
+}
+
+// warnReadableByOthers logs a warning if the existing file or directory at path is readable by
+// other users while its configured permissions, perm, wouldn't allow it: only files and
+// directories the CLI creates get perm, existing ones keep their own.
+func warnReadableByOthers(log *slog.Logger, path string, perm fs.FileMode) {
+	current, readable, err := configfile.ReadableByOthers(path, perm)
 	if err != nil {
-		return fmt.Errorf("parsing connections template: %w", err)
+		log.Warn("checking permissions", "path", path, "error", err)
+		return
+	}
+	if readable {
+		log.Warn("path is readable by other users", "path", path, "mode", fmt.Sprintf("%#o", current), "want", fmt.Sprintf("%#o", perm))
 	}
+}
 
-	if err := os.MkdirAll(path, os.ModePerm); err != nil {
-		return fmt.Errorf("creating aws connections path: %w", err)
+// planCredentialsFile renders the credentials file for accounts. With opts.merge set, the
+// generated profiles replace only the managed block of the existing file (see
+// configfile.MergeINI), leaving every other profile in it untouched; otherwise the file is
+// overwritten.
+func planCredentialsFile(path string, accounts []generator.Account, opts fileOptions) (plannedFile, error) {
+	content, err := renderCredentials(accounts)
+	if err != nil {
+		return plannedFile{}, err
 	}
+	f, err := planFile(filepath.Join(path, "credentials"), "AWS credentials file", content, opts, configfile.MergeINI)
+	f.redact = configfile.RedactINI
+	return f, err
+}
 
-	file, err := os.Create(filepath.Join(path, "aws.spc"))
+// planConfigFile renders the AWS config file for accounts. With fileOpts.merge set, the
+// generated profiles replace only the managed block of the existing file (see
+// configfile.MergeINI), leaving every other profile in it untouched; otherwise the file is
+// overwritten.
+func planConfigFile(path string, accounts []generator.Account, opts generator.ConfigOptions, fileOpts fileOptions) (plannedFile, error) {
+	content, err := renderConfig(accounts, opts)
 	if err != nil {
-		return fmt.Errorf("creating aws connections file: %w", err)
+		return plannedFile{}, err
 	}
-	defer func() { _ = file.Close() }()
+	f, err := planFile(filepath.Join(path, "config"), "AWS config file", content, fileOpts, configfile.MergeINI)
+	f.redact = configfile.RedactINI
+	return f, err
+}
 
-	if err := generator.RenderConnections(file, accounts, tmpl); err != nil {
-		return fmt.Errorf("rendering aws connections file: %w", err)
+// planConnectionsFile renders the Steampipe connections file for accounts. With
+// fileOpts.merge set, the generated connections replace only the managed block of the existing
+// file (see configfile.MergeSPC), leaving every other connection in it untouched; otherwise the
+// file is overwritten.
+func planConnectionsFile(path, templatePath string, accounts []generator.Account, opts generator.ConnectionsOptions, fileOpts fileOptions) (plannedFile, error) {
+	content, err := renderConnections(templatePath, accounts, opts)
+	if err != nil {
+		return plannedFile{}, err
 	}
+	return planFile(filepath.Join(path, "aws.spc"), "Steampipe connections file", content, fileOpts, configfile.MergeSPC)
+}
+
+func renderCredentials(accounts []generator.Account) ([]byte, error) {
+	var content bytes.Buffer
+	if err := generator.RenderCredentials(&content, accounts); err != nil {
+		return nil, fmt.Errorf("rendering aws credentials file: %w", err)
+	}
+	return content.Bytes(), nil
+}
 
-	if err := file.Close(); err != nil {
-		return fmt.Errorf("closing aws connections file: %w", err)
+func renderConfig(accounts []generator.Account, opts generator.ConfigOptions) ([]byte, error) {
+	var content bytes.Buffer
+	if err := generator.RenderConfig(&content, accounts, opts); err != nil {
+		return nil, fmt.Errorf("rendering aws config file: %w", err)
+	}
+	return content.Bytes(), nil
+}
+
+func renderConnections(templatePath string, accounts []generator.Account, opts generator.ConnectionsOptions) ([]byte, error) {
+	tmpl, err := generator.ParseConnectionsTemplate(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing connections template: %w", err)
+	}
+
+	var content bytes.Buffer
+	if err := generator.RenderConnections(&content, accounts, tmpl, opts); err != nil {
+		return nil, fmt.Errorf("rendering aws connections file: %w", err)
+	}
+	return content.Bytes(), nil
+}
+
+// plannedFile is a file run writes: the content it has, and the content it gets.
+type plannedFile struct {
+	path string
+	// description names the file in logs and errors, e.g. "AWS config file".
+	description string
+	// exists reports whether the file exists; existing is nil if it doesn't.
+	exists   bool
+	existing []byte
+	content  []byte
+	opts     fileOptions
+	// redact, if set, hides the secrets in the file's content from its diff, e.g.
+	// configfile.RedactINI.
+	redact func(content []byte) []byte
+}
+
+// diff returns the unified diff of writing f (see configfile.Diff), with its secrets redacted.
+func (f *plannedFile) diff() []byte {
+	existing, content := f.existing, f.content
+	if f.redact != nil {
+		existing, content = f.redact(existing), f.redact(content)
+	}
+	return configfile.Diff(f.path, existing, content)
+}
+
+// changed reports whether writing f would change it.
+func (f *plannedFile) changed() bool {
+	return !f.exists || !bytes.Equal(f.existing, f.content)
+}
+
+// write creates f's directory if needed and atomically writes its content (see
+// configfile.WriteFile), backing up the file it replaces.
+func (f *plannedFile) write() error {
+	if err := f.opts.mkdirAll(filepath.Dir(f.path)); err != nil {
+		return fmt.Errorf("creating %s directory: %w", f.description, err)
+	}
+	if err := configfile.WriteFile(f.path, f.content, f.opts.WriteOptions); err != nil {
+		return fmt.Errorf("writing %s: %w", f.description, err)
 	}
 	return nil
 }
 
+// mergeFunc merges generated content into a file's existing content, like configfile.MergeINI.
+type mergeFunc func(existing, generated []byte) ([]byte, error)
+
+// planFile reads file's current content, if it exists, and plans the content it gets for
+// generated: generated itself, or generated merged into the current content with mergeFunc if
+// opts.merge is set.
+func planFile(file, description string, generated []byte, opts fileOptions, mergeFunc mergeFunc) (plannedFile, error) {
+	f := plannedFile{path: file, description: description, content: generated, opts: opts}
+
+	existing, err := os.ReadFile(file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return plannedFile{}, fmt.Errorf("reading %s: %w", description, err)
+	default:
+		f.exists, f.existing = true, existing
+	}
+
+	if opts.merge {
+		if f.content, err = mergeFunc(f.existing, generated); err != nil {
+			return plannedFile{}, fmt.Errorf("merging into %s: %w", file, err)
+		}
+	}
+	return f, nil
+}
+
+// roleRules converts the --roleRule occurrences into generator rules.
+func roleRules(rules []cmd.RoleRule) []generator.RoleRule {
+	converted := make([]generator.RoleRule, 0, len(rules))
+	for _, rule := range rules {
+		converted = append(converted, generator.RoleRule(rule))
+	}
+	return converted
+}
+
 func main() {
 	root := cmd.NewRootCmd(func(ctx context.Context, log *slog.Logger, flags *cmd.Flags) error {
-		return run(ctx, log, flags, generator.New)
+		return run(ctx, log, os.Stdout, flags, generator.New)
 	})
-	if err := root.Execute(); err != nil {
-		os.Exit(1)
+	os.Exit(execute(root))
+}
+
+// execute runs root and returns the process exit code. It prints any error to root's error
+// output, as cobra would, but errChangesPending: a --dryRun run finding changes didn't fail, it
+// only exits with exitChangesPending.
+func execute(root *cobra.Command) int {
+	err := root.Execute()
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errChangesPending):
+		return exitChangesPending
+	default:
+		root.PrintErrln(root.ErrPrefix(), err.Error())
+		return 1
 	}
 }